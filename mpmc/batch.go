@@ -0,0 +1,227 @@
+package mpmc
+
+import (
+	"sync/atomic"
+
+	"github.com/hedzr/go-ringbuf/v2/mpmc/state"
+)
+
+// EnqueueBatch puts items into the ring buffer by reserving a
+// contiguous range of slots with one CAS operation.
+//
+// If the ring buffer has fewer free slots than len(items), only
+// the leading n items are put, and [ErrQueueFull] is returned.
+func (rb *ringBuf[T]) EnqueueBatch(items []T) (n int, err error) { //nolint:revive
+	if err = rb.enter(producing); err != nil {
+		return
+	}
+	defer rb.leave(producing)
+
+	if len(items) == 0 {
+		return
+	}
+	if rb.IsClosed() {
+		err = ErrQueueClosed
+		return
+	}
+
+	var tail, head, nt, free uint32
+	for {
+		// load tail before head for producers, so that the head
+		// is never older than the tail, and the free slots will
+		// never be over-estimated once the tail CAS succeeded.
+		tail = atomic.LoadUint32(&rb.tail)
+		head = atomic.LoadUint32(&rb.head)
+
+		isEmpty := head == tail
+		if isEmpty && head == MaxUint32 {
+			err = rb.notReady()
+			return
+		}
+
+		free = rb.capModMask - rb.qty(head, tail)
+		if free == 0 {
+			err = rb.full()
+			return
+		}
+
+		n = len(items)
+		if uint32(n) > free {
+			n = int(free)
+		}
+		nt = (tail + uint32(n)) & rb.capModMask
+		if atomic.CompareAndSwapUint32(&rb.tail, tail, nt) {
+			break
+		}
+		rb.retried()
+	}
+
+	for i := 0; i < n; i++ {
+		holder := rb.at((tail + uint32(i)) & rb.capModMask)
+		rb.claim(holder, 0, 2) //nolint:gomnd
+		rb.store(holder, items[i])
+		if !atomic.CompareAndSwapUint64(&holder.readWrite, 2, 1) { //nolint:gomnd
+			err = rb.raced() // never happens
+		}
+	}
+
+	if state.VerboseEnabled {
+		state.Verbose("[W] enqueued batch", "tail", tail, "new-tail", nt, "head", head, "n", n)
+	}
+	rb.produced(uint64(n), uint64(rb.qty(head, tail))+uint64(n))
+
+	if err == nil && n < len(items) {
+		err = rb.full()
+	}
+	return
+}
+
+// DequeueBatch takes up to len(dst) elements from the ring buffer
+// into dst by reserving a contiguous range of slots with one CAS
+// operation.
+//
+// It returns [ErrQueueEmpty] only if nothing could be taken.
+func (rb *ringBuf[T]) DequeueBatch(dst []T) (n int, err error) { //nolint:revive
+	if err = rb.enter(consuming); err != nil {
+		return
+	}
+	defer rb.leave(consuming)
+
+	if len(dst) == 0 {
+		return
+	}
+
+	var tail, head, nh, qty uint32
+	for {
+		head = atomic.LoadUint32(&rb.head)
+		tail = atomic.LoadUint32(&rb.tail)
+
+		isEmpty := head == tail
+		if isEmpty {
+			if head == MaxUint32 {
+				err = rb.notReady()
+				return
+			}
+			if rb.IsClosed() {
+				err = ErrQueueClosed
+				return
+			}
+			err = rb.empty()
+			return
+		}
+
+		qty = rb.qty(head, tail)
+		n = len(dst)
+		if uint32(n) > qty {
+			n = int(qty)
+		}
+		nh = (head + uint32(n)) & rb.capModMask
+		if atomic.CompareAndSwapUint32(&rb.head, head, nh) {
+			break
+		}
+		rb.retried()
+	}
+
+	for i := 0; i < n; i++ {
+		holder := rb.at((head + uint32(i)) & rb.capModMask)
+		rb.claim(holder, 1, 3) //nolint:gomnd
+		dst[i] = rb.load(holder)
+		if !atomic.CompareAndSwapUint64(&holder.readWrite, 3, 0) { //nolint:gomnd
+			err = rb.raced() // never happens
+		}
+	}
+
+	if state.VerboseEnabled {
+		state.Verbose("[R] dequeued batch", "head", head, "new-head", nh, "tail", tail, "n", n)
+	}
+	rb.consumed(uint64(n))
+	return
+}
+
+// EnqueueBatch puts all items into the overlapped ring buffer, the
+// oldest elements will be overwritten if there are no enough free
+// slots.
+func (rb *orbuf[T]) EnqueueBatch(items []T) (n int, err error) { //nolint:revive
+	_, err = rb.EnqueueBatchM(items)
+	if err == nil {
+		n = len(items)
+	}
+	return
+}
+
+// EnqueueBatchM puts all items into the overlapped ring buffer and
+// returns how many elements were overwritten, which equals the
+// total overwrites of calling [orbuf.EnqueueM] for each item.
+//
+// If len(items) is greater than the usable capacity, the leading
+// items are counted as overwritten by the trailing ones.
+func (rb *orbuf[T]) EnqueueBatchM(items []T) (overwrites uint32, err error) { //nolint:revive
+	if err = rb.enter(producing); err != nil {
+		return
+	}
+	defer rb.leave(producing)
+
+	if len(items) == 0 {
+		return
+	}
+	if rb.IsClosed() {
+		err = ErrQueueClosed
+		return
+	}
+
+	put := uint64(len(items))
+	if k := uint32(len(items)); k > rb.capModMask {
+		overwrites = k - rb.capModMask
+		items = items[overwrites:]
+	}
+	k := uint32(len(items))
+
+	var tail, head, nt, nh, free uint32
+	for {
+		tail = atomic.LoadUint32(&rb.tail)
+		head = atomic.LoadUint32(&rb.head)
+
+		isEmpty := head == tail
+		if isEmpty && head == MaxUint32 {
+			err = rb.notReady()
+			return
+		}
+
+		free = rb.capModMask - rb.qty(head, tail)
+		if free < k {
+			// drop the oldest elements to make room
+			nh = (head + k - free) & rb.capModMask
+			if !atomic.CompareAndSwapUint32(&rb.head, head, nh) {
+				rb.retried()
+				continue // head CAS failed, retry with fresh values
+			}
+			overwrites += k - free
+		}
+
+		nt = (tail + k) & rb.capModMask
+		if atomic.CompareAndSwapUint32(&rb.tail, tail, nt) {
+			break
+		}
+		rb.retried()
+	}
+
+	for i := uint32(0); i < k; i++ {
+		holder := rb.at((tail + i) & rb.capModMask)
+		for spins := 0; !atomic.CompareAndSwapUint64(&holder.readWrite, 0, 2) && //nolint:gomnd
+			!atomic.CompareAndSwapUint64(&holder.readWrite, 1, 2); { //nolint:gomnd
+			spins++
+			rb.idle(spins)
+		}
+		rb.store(holder, items[i])
+		if !atomic.CompareAndSwapUint64(&holder.readWrite, 2, 1) { //nolint:gomnd
+			err = rb.raced() // never happens
+		}
+	}
+
+	if state.VerboseEnabled {
+		state.Verbose("[W] enqueued batch", "tail", tail, "new-tail", nt, "head", head, "n", k, "overwrites", overwrites)
+	}
+	rb.overwritten(uint64(overwrites))
+	rb.produced(put, uint64(min(rb.qty(head, tail)+k, rb.capModMask)))
+	return
+}
@@ -0,0 +1,172 @@
+package mpmc
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRingBuf_EnqueueBatch(t *testing.T) {
+	rb := New[int](8) // 7 usable slots
+	defer rb.Close()
+
+	n, err := rb.EnqueueBatch([]int{1, 2, 3, 4, 5})
+	if err != nil || n != 5 {
+		t.Fatalf("expect 5 items put but got %v, err: %v", n, err)
+	}
+
+	// partial success
+	n, err = rb.EnqueueBatch([]int{6, 7, 8, 9})
+	if !errors.Is(err, ErrQueueFull) || n != 2 {
+		t.Fatalf("expect 2 items put with ErrQueueFull but got %v, err: %v", n, err)
+	}
+
+	if n, err = rb.EnqueueBatch([]int{8}); !errors.Is(err, ErrQueueFull) || n != 0 {
+		t.Fatalf("expect ErrQueueFull but got %v, err: %v", n, err)
+	}
+
+	dst := make([]int, 3)
+	if n, err = rb.DequeueBatch(dst); err != nil || n != 3 {
+		t.Fatalf("expect 3 items taken but got %v, err: %v", n, err)
+	}
+	if fmt.Sprint(dst) != "[1 2 3]" {
+		t.Fatalf("unexpected items: %v", dst)
+	}
+
+	// wrap around the end of the buffer
+	if n, err = rb.EnqueueBatch([]int{8, 9, 10}); err != nil || n != 3 {
+		t.Fatalf("expect 3 items put but got %v, err: %v", n, err)
+	}
+
+	dst = make([]int, 16)
+	if n, err = rb.DequeueBatch(dst); err != nil || n != 7 {
+		t.Fatalf("expect 7 items taken but got %v, err: %v", n, err)
+	}
+	if fmt.Sprint(dst[:n]) != "[4 5 6 7 8 9 10]" {
+		t.Fatalf("unexpected items: %v", dst[:n])
+	}
+
+	if n, err = rb.DequeueBatch(dst); !errors.Is(err, ErrQueueEmpty) || n != 0 {
+		t.Fatalf("expect ErrQueueEmpty but got %v, err: %v", n, err)
+	}
+	if rb.Size() != 0 {
+		t.Fatalf("expect an empty ring buffer but got size %v", rb.Size())
+	}
+}
+
+func TestOverlappedRingBuf_EnqueueBatchM(t *testing.T) {
+	rb := NewOverlappedRingBuffer[int](8) // 7 usable slots
+	defer rb.Close()
+
+	c, err := rb.EnqueueBatchM([]int{1, 2, 3, 4, 5})
+	if err != nil || c != 0 {
+		t.Fatalf("expect no overwrites but got %v, err: %v", c, err)
+	}
+
+	if c, err = rb.EnqueueBatchM([]int{6, 7, 8, 9}); err != nil || c != 2 {
+		t.Fatalf("expect 2 overwrites but got %v, err: %v", c, err)
+	}
+	if fmt.Sprint(rb) != "[3,4,5,6,7,8,9,]/7" {
+		t.Fatalf("unexpected elements: %v", rb)
+	}
+
+	// larger than the usable capacity
+	items := make([]int, 10)
+	for i := range items {
+		items[i] = 10 + i
+	}
+	if c, err = rb.EnqueueBatchM(items); err != nil || c != 7+3 {
+		t.Fatalf("expect 10 overwrites but got %v, err: %v", c, err)
+	}
+	if fmt.Sprint(rb) != "[13,14,15,16,17,18,19,]/7" {
+		t.Fatalf("unexpected elements: %v", rb)
+	}
+
+	n, err := rb.EnqueueBatch([]int{20})
+	if err != nil || n != 1 {
+		t.Fatalf("expect 1 item put but got %v, err: %v", n, err)
+	}
+
+	dst := make([]int, 8)
+	if n, err = rb.DequeueBatch(dst); err != nil || n != 7 {
+		t.Fatalf("expect 7 items taken but got %v, err: %v", n, err)
+	}
+	if fmt.Sprint(dst[:n]) != "[14 15 16 17 18 19 20]" {
+		t.Fatalf("unexpected items: %v", dst[:n])
+	}
+}
+
+func TestRingBuf_BatchMPMC(t *testing.T) {
+	const producers, consumers, cnt, batch = 4, 4, 5000, 7
+
+	rb := New[int](64)
+	defer rb.Close()
+
+	var wg sync.WaitGroup
+	var sum, got int64
+	for i := 0; i < producers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			items := make([]int, 0, batch)
+			for j := 1; j <= cnt; j++ {
+				items = append(items, j)
+				if len(items) < batch && j < cnt {
+					continue
+				}
+				for len(items) > 0 {
+					n, err := rb.EnqueueBatch(items)
+					if err != nil && !errors.Is(err, ErrQueueFull) {
+						t.Errorf("[PUT] failed: %v", err)
+						return
+					}
+					items = items[n:]
+					if len(items) > 0 {
+						runtime.Gosched()
+					}
+				}
+				items = items[:0]
+			}
+		}()
+	}
+	for i := 0; i < consumers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dst := make([]int, batch)
+			for atomic.LoadInt64(&got) < producers*cnt {
+				n, err := rb.DequeueBatch(dst)
+				if err != nil && !errors.Is(err, ErrQueueEmpty) {
+					t.Errorf("[GET] failed: %v", err)
+					return
+				}
+				if n == 0 {
+					runtime.Gosched()
+				}
+				for _, it := range dst[:n] {
+					atomic.AddInt64(&sum, int64(it))
+				}
+				atomic.AddInt64(&got, int64(n))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if expect := int64(producers * cnt * (cnt + 1) / 2); sum != expect {
+		t.Fatalf("expect sum %v but got %v", expect, sum)
+	}
+}
+
+func BenchmarkRingBuf_PutBatch(b *testing.B) {
+	rb := New[int](1024)
+	items := make([]int, 32)
+	dst := make([]int, 32)
+	b.ResetTimer()
+	for i := 0; i < b.N; i += len(items) {
+		_, _ = rb.EnqueueBatch(items)
+		_, _ = rb.DequeueBatch(dst)
+	}
+}
@@ -0,0 +1,185 @@
+package mpmc
+
+import (
+	"sync/atomic"
+)
+
+// DequeueIf takes the head element only if pred reports true for it,
+// otherwise ok is false and the element is kept. It returns the same
+// errors as Dequeue if the ring buffer is empty.
+//
+// The head slot is held while pred is being called, so the element
+// cannot be taken by another consumer in the meantime, and it's
+// removed by the head CAS like Dequeue. pred must be quick, and it
+// must not operate on the ring buffer. If pred panics, the slot is
+// let go before the panic goes on.
+func (rb *ringBuf[T]) DequeueIf(pred func(T) bool) (item T, ok bool, err error) {
+	if err = rb.enter(consuming); err != nil {
+		return
+	}
+	defer rb.leave(consuming)
+
+	var held *rbItem[T] // the slot held while calling pred
+	defer func() {
+		if held != nil {
+			atomic.CompareAndSwapUint64(&held.readWrite, 3, 1) //nolint:gomnd
+		}
+	}()
+
+	for spins := 0; ; spins++ {
+		head := atomic.LoadUint32(&rb.head)
+		tail := atomic.LoadUint32(&rb.tail)
+		if head == tail {
+			if rb.IsClosed() {
+				err = ErrQueueClosed
+			} else {
+				err = rb.empty()
+			}
+			return
+		}
+
+		holder := rb.at(head)
+		if atomic.CompareAndSwapUint64(&holder.readWrite, 1, 3) { //nolint:gomnd
+			if atomic.LoadUint32(&rb.head) == head {
+				held = holder
+				keep := !pred(rb.view(holder))
+				held = nil
+				if keep {
+					atomic.CompareAndSwapUint64(&holder.readWrite, 3, 1) //nolint:gomnd
+					return
+				}
+				if atomic.CompareAndSwapUint32(&rb.head, head, (head+1)&rb.capModMask) {
+					item, ok = rb.load(holder), true
+					atomic.CompareAndSwapUint64(&holder.readWrite, 3, 0) //nolint:gomnd
+					rb.consumed(1)
+					return
+				}
+			}
+			atomic.CompareAndSwapUint64(&holder.readWrite, 3, 1) //nolint:gomnd
+		}
+		rb.idle(spins + 1) // being written, or taken by another consumer
+	}
+}
+
+// DequeueWhile takes the leading elements into dst while pred reports
+// true for them, and returns how many were taken. Each one is taken
+// by DequeueIf, so the run might be interleaved with the elements
+// taken by the other consumers.
+//
+// It returns an error only if nothing was taken since the ring buffer
+// is empty, see also DequeueBatch.
+func (rb *ringBuf[T]) DequeueWhile(pred func(T) bool, dst []T) (n int, err error) { //nolint:revive
+	return dequeueWhile(rb.DequeueIf, pred, dst)
+}
+
+func dequeueWhile[T any](dequeueIf func(pred func(T) bool) (T, bool, error), pred func(T) bool, dst []T) (n int, err error) {
+	for n < len(dst) {
+		it, ok, e := dequeueIf(pred)
+		if e != nil {
+			if n == 0 {
+				err = e
+			}
+			return
+		}
+		if !ok {
+			return
+		}
+		dst[n] = it
+		n++
+	}
+	return
+}
+
+// DequeueIf takes the head element only if pred reports true for it,
+// see also [ringBuf.DequeueIf].
+func (rb *topoRingBuf[T]) DequeueIf(pred func(T) bool) (item T, ok bool, err error) {
+	if rb.multiConsumers {
+		return rb.ringBuf.DequeueIf(pred)
+	}
+
+	// the caller is the single consumer, nobody else can take the
+	// head element between peeking and taking it.
+	var items [1]T
+	if _, err = rb.peek(0, items[:]); err != nil {
+		err = rb.rejected(err)
+		return
+	}
+	if !pred(items[0]) {
+		return
+	}
+	if _, err = rb.dequeue(items[:]); err == nil {
+		item, ok = items[0], true
+	}
+	return
+}
+
+func (rb *topoRingBuf[T]) DequeueWhile(pred func(T) bool, dst []T) (n int, err error) { //nolint:revive
+	return dequeueWhile(rb.DequeueIf, pred, dst)
+}
+
+// DequeueIf takes the head element only if pred reports true for it,
+// see also [ringBuf.DequeueIf]. The head slot is held by [peekedSeq]
+// while pred is being called.
+func (rb *seqRingBuf[T]) DequeueIf(pred func(T) bool) (item T, ok bool, err error) {
+	if err = rb.enter(consuming); err != nil {
+		return
+	}
+	defer rb.leave(consuming)
+
+	var held *rbItem[T] // the slot held while calling pred
+	var heldPos uint64
+	defer func() {
+		if held != nil {
+			atomic.CompareAndSwapUint64(&held.readWrite, heldPos+1|peekedSeq, heldPos+1)
+		}
+	}()
+
+	for spins := 0; ; spins++ {
+		pos := atomic.LoadUint64(&rb.deqPos)
+		holder := rb.slot(pos)
+		seq := rb.loadSeq(holder)
+		if int64(seq-(pos+1)) < 0 {
+			err = rb.errEmpty() // not published by the producer yet
+			return
+		}
+
+		if atomic.CompareAndSwapUint64(&holder.readWrite, pos+1, pos+1|peekedSeq) {
+			if atomic.LoadUint64(&rb.deqPos) == pos {
+				held, heldPos = holder, pos
+				keep := !pred(rb.view(holder))
+				held = nil
+				if keep {
+					atomic.CompareAndSwapUint64(&holder.readWrite, pos+1|peekedSeq, pos+1)
+					return
+				}
+				if atomic.CompareAndSwapUint64(&rb.deqPos, pos, pos+1) {
+					item, ok = rb.load(holder), true
+					atomic.StoreUint64(&holder.readWrite, pos+rb.size)
+					rb.consumed(1)
+					return
+				}
+			}
+			atomic.CompareAndSwapUint64(&holder.readWrite, pos+1|peekedSeq, pos+1)
+		}
+		rb.idle(spins + 1) // held by a peeker, or taken by another consumer
+	}
+}
+
+func (rb *seqRingBuf[T]) DequeueWhile(pred func(T) bool, dst []T) (n int, err error) { //nolint:revive
+	return dequeueWhile(rb.DequeueIf, pred, dst)
+}
+
+func (g *growRingBuf[T]) DequeueIf(pred func(T) bool) (item T, ok bool, err error) { //nolint:revive
+	s := g.enter()
+	defer func() {
+		g.leave(s) // even if pred panics, or a resize would wait forever
+		if ok {
+			g.notFull.signal()
+		}
+	}()
+	return s.rb.DequeueIf(pred)
+}
+
+func (g *growRingBuf[T]) DequeueWhile(pred func(T) bool, dst []T) (n int, err error) { //nolint:revive
+	return dequeueWhile(g.DequeueIf, pred, dst)
+}
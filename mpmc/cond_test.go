@@ -0,0 +1,148 @@
+package mpmc
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDequeueIf(t *testing.T) {
+	even := func(v int) bool { return v%2 == 0 }
+	for _, c := range allCreators(1) {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.create(8)
+			defer rb.Close()
+
+			if _, _, err := rb.DequeueIf(even); !errors.Is(err, ErrQueueEmpty) {
+				t.Fatalf("expect ErrQueueEmpty but got %v", err)
+			}
+			for _, v := range []int{1, 2, 4, 6, 7} {
+				checkerr(t, rb.Enqueue(v))
+			}
+			if it, ok, err := rb.DequeueIf(even); err != nil || ok || rb.Size() != 5 {
+				t.Fatalf("expect 1 kept, but got %v (%v), err: %v", it, ok, err)
+			}
+			if it, ok, err := rb.DequeueIf(func(v int) bool { return v == 1 }); err != nil || !ok || it != 1 {
+				t.Fatalf("expect 1 taken, but got %v (%v), err: %v", it, ok, err)
+			}
+
+			dst := make([]int, 8)
+			if n, err := rb.DequeueWhile(even, dst[:2]); err != nil || !reflect.DeepEqual(dst[:n], []int{2, 4}) {
+				t.Fatalf("expect [2 4] taken, but got %v, err: %v", dst[:n], err)
+			}
+			if n, err := rb.DequeueWhile(even, dst); err != nil || !reflect.DeepEqual(dst[:n], []int{6}) {
+				t.Fatalf("expect [6] taken, but got %v, err: %v", dst[:n], err)
+			}
+			if it, err := rb.Peek(); err != nil || it != 7 {
+				t.Fatalf("expect 7 kept, but got %v, err: %v", it, err)
+			}
+			if n, err := rb.DequeueWhile(func(int) bool { return true }, dst); err != nil || n != 1 {
+				t.Fatalf("expect 1 taken, but got %v, err: %v", n, err)
+			}
+			if _, err := rb.DequeueWhile(even, dst); !errors.Is(err, ErrQueueEmpty) {
+				t.Fatalf("expect ErrQueueEmpty but got %v", err)
+			}
+		})
+	}
+}
+
+// TestDequeueIf_Panic panics in pred, the head element must be left
+// for the next consumer, and the ring buffer still resizable.
+func TestDequeueIf_Panic(t *testing.T) {
+	for _, c := range allCreators(2) {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.create(8)
+			defer rb.Close()
+
+			checkerr(t, rb.Enqueue(1))
+			func() {
+				defer func() {
+					if r := recover(); r == nil {
+						t.Fatal("expect the panic of pred to go on")
+					}
+				}()
+				_, _, _ = rb.DequeueIf(func(int) bool { panic("pred") })
+			}()
+
+			if g, ok := rb.(*growRingBuf[int]); ok {
+				checkerr(t, g.Resize(16))
+			}
+			if it, err := rb.Dequeue(); err != nil || it != 1 {
+				t.Fatalf("expect 1 after the panic, but got %v, err: %v", it, err)
+			}
+			rb.Reset() // waits for nobody
+		})
+	}
+}
+
+// TestDequeueIf_Concurrent takes the elements by DequeueIf and
+// Dequeue concurrently, every element must be taken exactly once,
+// and DequeueIf must take only the matching ones.
+func TestDequeueIf_Concurrent(t *testing.T) {
+	const consumers, count = 4, 5000
+	for _, c := range []struct {
+		name   string
+		create func(capacity uint32, opts ...Opt[int]) RingBuffer[int]
+	}{
+		{"MPMC", New[int]},
+		{"SPMC", NewSPMC[int]},
+		{"Sequenced", NewSequenced[int]},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.create(16)
+			defer rb.Close()
+
+			var sum, taken int64
+			var mismatch atomic.Value
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 1; i <= count; {
+					if rb.Enqueue(i) == nil {
+						i++
+					} else {
+						runtime.Gosched()
+					}
+				}
+			}()
+			for q := 0; q < consumers; q++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for atomic.LoadInt64(&taken) < count {
+						var it int
+						var ok bool
+						var err error
+						if q%2 == 0 {
+							it, ok, err = rb.DequeueIf(func(v int) bool { return v%3 != 0 })
+							if ok && it%3 == 0 {
+								mismatch.Store(it)
+							}
+						} else {
+							it, err = rb.Dequeue()
+							ok = err == nil
+						}
+						if ok {
+							atomic.AddInt64(&sum, int64(it))
+							atomic.AddInt64(&taken, 1)
+						} else {
+							runtime.Gosched()
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			if expect := int64(count * (count + 1) / 2); sum != expect {
+				t.Fatalf("expect sum %v but got %v", expect, sum)
+			}
+			if v := mismatch.Load(); v != nil {
+				t.Fatalf("%v is taken by DequeueIf unexpectedly", v)
+			}
+		})
+	}
+}
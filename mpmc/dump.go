@@ -0,0 +1,264 @@
+package mpmc
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+// DumpOptions selects what Dump prints besides the elements.
+type DumpOptions struct {
+	// Slots prints the raw state of each slot from the head, for a
+	// whole lap of the ring buffer.
+	Slots bool
+	// Counters prints the head and tail indices, the size, and the
+	// other counters of the ring buffer.
+	Counters bool
+}
+
+// slotView is a slot observed without blocking. The value is copied
+// out only if the slot could be held at once, otherwise it's in
+// flight: being written, or being taken by a consumer.
+type slotView[T any] struct {
+	index uint64 // the index in the backing array
+	state uint64 // the raw readWrite field
+	value T
+	seen  bool
+}
+
+// observation is a weakly consistent view of a ring buffer. The first
+// size slots are the elements, the remaining ones are observed only
+// if [DumpOptions.Slots] is specified.
+type observation[T any] struct {
+	head, tail uint64
+	size, cap  uint64
+	closed     bool
+	notReady   bool
+	metrics    bool  // the counters in stats are collected
+	stats      Stats // the counters only, see [WithMetrics]
+	slots      []slotView[T]
+}
+
+func (o *observation[T]) items() (items []T) {
+	for _, s := range o.slots[:o.size] {
+		if s.seen {
+			items = append(items, s.value)
+		}
+	}
+	return
+}
+
+// String returns the elements in the form "[a,b,]/size", the ones in
+// flight are marked as <in-flight>.
+func (o *observation[T]) String() string {
+	var sb strings.Builder
+	_, _ = sb.WriteRune('[')
+	for _, s := range o.slots[:o.size] {
+		if s.seen {
+			_, _ = sb.WriteString(fmt.Sprintf("%v,", s.value))
+		} else {
+			_, _ = sb.WriteString("<in-flight>,")
+		}
+	}
+	_, _ = sb.WriteString(fmt.Sprintf("]/%v", o.size))
+	return sb.String()
+}
+
+func (o *observation[T]) dump(w io.Writer, opts DumpOptions) (err error) {
+	var sb strings.Builder
+	if opts.Counters {
+		_, _ = sb.WriteString(fmt.Sprintf("head=%v tail=%v size=%v cap=%v closed=%v not-ready=%v",
+			o.head, o.tail, o.size, o.cap, o.closed, o.notReady))
+		if s := &o.stats; o.metrics {
+			_, _ = sb.WriteString(fmt.Sprintf(" enqueued=%v dequeued=%v full=%v empty=%v cas-retries=%v yields=%v overwrites=%v high-water=%v",
+				s.Enqueued, s.Dequeued, s.FullRejections, s.EmptyRejections, s.CASRetries, s.Yields, s.Overwrites, s.HighWater))
+		}
+		_, _ = sb.WriteRune('\n')
+	}
+	_, _ = sb.WriteString(o.String())
+	_, _ = sb.WriteRune('\n')
+	if opts.Slots {
+		for _, s := range o.slots {
+			_, _ = sb.WriteString(fmt.Sprintf("#%v state=%v", s.index, s.state))
+			if s.seen {
+				_, _ = sb.WriteString(fmt.Sprintf(" value=%v", s.value))
+			}
+			_, _ = sb.WriteRune('\n')
+		}
+	}
+	_, err = io.WriteString(w, sb.String())
+	return
+}
+
+// Snapshot returns the elements from head to tail without consuming
+// them, and without blocking: a slot being written or taken is left
+// out rather than waited for.
+//
+// It's weakly consistent like All, but each element is copied out
+// while its slot is held, so it's never torn, and an element taken
+// by a consumer meanwhile is left out too.
+func (rb *ringBuf[T]) Snapshot() []T { o := rb.observe(false); return o.items() }
+
+// Dump writes the elements to w without blocking, see also Snapshot.
+// The slots in flight are marked as <in-flight>.
+func (rb *ringBuf[T]) Dump(w io.Writer, opts DumpOptions) error {
+	o := rb.observe(opts.Slots)
+	return o.dump(w, opts)
+}
+
+func (rb *ringBuf[T]) String() string { o := rb.observe(false); return o.String() }
+
+// observe takes an observation of the elements, or all of the slots.
+func (rb *ringBuf[T]) observe(all bool) (o observation[T]) {
+	rb.observed(&o)
+	if o.notReady = rb.enter(observing) != nil; o.notReady {
+		return
+	}
+	defer rb.leave(observing)
+	rb.scan(&o, all)
+	return
+}
+
+// scan fills the slots of an observation, the caller must have
+// registered itself or quiesced the ring buffer.
+func (rb *ringBuf[T]) scan(o *observation[T], all bool) {
+	head := atomic.LoadUint32(&rb.head)
+	tail := atomic.LoadUint32(&rb.tail)
+	o.head, o.tail, o.size = uint64(head), uint64(tail), uint64(rb.qty(head, tail))
+	n := o.size
+	if all {
+		n = uint64(rb.cap)
+	}
+	for i := uint32(0); uint64(i) < n; i++ {
+		index := (head + i) & rb.capModMask
+		holder := rb.at(index)
+		s := slotView[T]{index: uint64(index), state: atomic.LoadUint64(&holder.readWrite)}
+		if s.state == 1 && atomic.CompareAndSwapUint64(&holder.readWrite, 1, 3) { //nolint:gomnd
+			// the slot might have been refilled in a later lap, if
+			// the head has passed it.
+			if uint64(i) >= o.size || rb.qty(head, atomic.LoadUint32(&rb.head)) <= i {
+				s.value, s.seen = rb.view(holder), true
+			}
+			atomic.CompareAndSwapUint64(&holder.readWrite, 3, 1) //nolint:gomnd
+		}
+		o.slots = append(o.slots, s)
+	}
+}
+
+// observed fills the counters of an observation.
+func (rb *ringBuf[T]) observed(o *observation[T]) {
+	o.cap = uint64(rb.cap)
+	o.closed = rb.IsClosed()
+	if m := rb.metrics.Load(); m != nil {
+		o.metrics = true
+		m.fill(&o.stats)
+	}
+}
+
+// Snapshot returns the elements without blocking, see also
+// [ringBuf.Snapshot].
+//
+// In SPSC mode there are no slot states to hold the slots, so the
+// elements are accurate only if it's called by the consumer.
+func (rb *topoRingBuf[T]) Snapshot() []T { o := rb.observe(false); return o.items() }
+
+func (rb *topoRingBuf[T]) Dump(w io.Writer, opts DumpOptions) error { //nolint:revive
+	o := rb.observe(opts.Slots)
+	return o.dump(w, opts)
+}
+
+func (rb *topoRingBuf[T]) String() string { o := rb.observe(false); return o.String() }
+
+func (rb *topoRingBuf[T]) observe(all bool) (o observation[T]) {
+	if rb.multiProducers || rb.multiConsumers {
+		return rb.ringBuf.observe(all)
+	}
+	rb.observed(&o)
+	if o.notReady = rb.enter(observing) != nil; o.notReady {
+		return
+	}
+	defer rb.leave(observing)
+	rb.scan(&o, all)
+	return
+}
+
+func (rb *topoRingBuf[T]) scan(o *observation[T], all bool) {
+	if rb.multiProducers || rb.multiConsumers {
+		rb.ringBuf.scan(o, all)
+		return
+	}
+	head := atomic.LoadUint32(&rb.head)
+	tail := atomic.LoadUint32(&rb.tail)
+	o.head, o.tail, o.size = uint64(head), uint64(tail), uint64(rb.qty(head, tail))
+	n := o.size
+	if all {
+		n = uint64(rb.cap)
+	}
+	for i := uint32(0); uint64(i) < n; i++ {
+		index := (head + i) & rb.capModMask
+		holder := rb.at(index)
+		s := slotView[T]{index: uint64(index), state: atomic.LoadUint64(&holder.readWrite)}
+		if uint64(i) < o.size {
+			s.value, s.seen = rb.view(holder), true
+		}
+		o.slots = append(o.slots, s)
+	}
+}
+
+// Snapshot returns the elements without blocking, see also
+// [ringBuf.Snapshot].
+func (rb *seqRingBuf[T]) Snapshot() []T { o := rb.observe(false); return o.items() }
+
+func (rb *seqRingBuf[T]) Dump(w io.Writer, opts DumpOptions) error { //nolint:revive
+	o := rb.observe(opts.Slots)
+	return o.dump(w, opts)
+}
+
+func (rb *seqRingBuf[T]) String() string { o := rb.observe(false); return o.String() }
+
+// observe takes an observation, the slots are held by [peekedSeq].
+func (rb *seqRingBuf[T]) observe(all bool) (o observation[T]) {
+	rb.observed(&o)
+	o.cap = rb.size
+	if o.notReady = rb.enter(observing) != nil; o.notReady {
+		return
+	}
+	defer rb.leave(observing)
+	rb.scan(&o, all)
+	return
+}
+
+// scan fills the slots of an observation, see also [ringBuf.scan].
+func (rb *seqRingBuf[T]) scan(o *observation[T], all bool) {
+	deq := atomic.LoadUint64(&rb.deqPos)
+	enq := atomic.LoadUint64(&rb.enqPos) &^ sealedPos
+	o.head, o.tail = deq, enq
+	if enq > deq {
+		o.size = min(enq-deq, rb.size)
+	}
+	n := o.size
+	if all {
+		n = rb.size
+	}
+	for pos := deq; pos-deq < n; pos++ {
+		holder := rb.slot(pos)
+		s := slotView[T]{index: pos & rb.mask, state: atomic.LoadUint64(&holder.readWrite)}
+		if rb.exactCapacity {
+			s.index = pos % rb.size
+		}
+		if s.state == pos+1 && atomic.CompareAndSwapUint64(&holder.readWrite, pos+1, pos+1|peekedSeq) {
+			if atomic.LoadUint64(&rb.deqPos) <= pos {
+				s.value, s.seen = rb.view(holder), true
+			}
+			atomic.CompareAndSwapUint64(&holder.readWrite, pos+1|peekedSeq, pos+1)
+		}
+		o.slots = append(o.slots, s)
+	}
+}
+
+func (g *growRingBuf[T]) Snapshot() []T { return within(g, (*seqRingBuf[T]).Snapshot) } //nolint:revive
+
+func (g *growRingBuf[T]) Dump(w io.Writer, opts DumpOptions) error { //nolint:revive
+	return within(g, func(rb *seqRingBuf[T]) error { return rb.Dump(w, opts) })
+}
@@ -0,0 +1,110 @@
+package mpmc
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	for _, c := range allCreators(1) {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.create(8)
+			defer rb.Close()
+
+			if got := rb.Snapshot(); got != nil {
+				t.Fatalf("expect nothing but got %v", got)
+			}
+			for i := 1; i <= 3; i++ {
+				checkerr(t, rb.Enqueue(i))
+			}
+
+			// a reserved slot is in flight till it's committed, the
+			// single producer publishes it on Commit.
+			_, ticket, err := rb.Reserve()
+			checkerr(t, err)
+			expect := "[1,2,3,<in-flight>,]/4"
+			if c.producers == 1 {
+				expect = "[1,2,3,]/3"
+			}
+			if got := rb.Snapshot(); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+				t.Fatalf("expect [1 2 3] but got %v", got)
+			}
+			if got := rb.(interface{ String() string }).String(); got != expect {
+				t.Fatalf("expect %v but got %v", expect, got)
+			}
+			rb.Commit(ticket)
+
+			var sb strings.Builder
+			checkerr(t, rb.Dump(&sb, DumpOptions{Slots: true, Counters: true}))
+			lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+			if len(lines) != 2+int(rb.Cap()) ||
+				!strings.HasPrefix(lines[0], "head=0 tail=4 size=4 ") ||
+				lines[1] != "[1,2,3,0,]/4" ||
+				!strings.HasPrefix(lines[2], "#0 state=") || !strings.HasSuffix(lines[2], " value=1") {
+				t.Fatalf("unexpected dump:\n%v", sb.String())
+			}
+		})
+	}
+}
+
+// TestSnapshot_Concurrent takes the snapshots while the producers and
+// consumers are running, it must never block or see a torn element.
+func TestSnapshot_Concurrent(t *testing.T) {
+	const count = 5000
+	for _, c := range []struct {
+		name   string
+		create func(capacity uint32, opts ...Opt[pair]) RingBuffer[pair]
+	}{
+		{"MPMC", New[pair]},
+		{"MPSC", NewMPSC[pair]},
+		{"Sequenced", NewSequenced[pair]},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.create(16)
+			defer rb.Close()
+
+			var taken int64
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for i := 1; i <= count; {
+					if rb.Enqueue(pair{i, -i}) == nil {
+						i++
+					} else {
+						runtime.Gosched()
+					}
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for atomic.LoadInt64(&taken) < count {
+					if _, err := rb.Dequeue(); err == nil {
+						atomic.AddInt64(&taken, 1)
+					} else {
+						runtime.Gosched()
+					}
+				}
+			}()
+
+			var sb strings.Builder
+			for atomic.LoadInt64(&taken) < count {
+				last := 0
+				for _, it := range rb.Snapshot() {
+					if it.a != -it.b || it.a <= last {
+						t.Fatalf("expect an element after %v, but got %v", last, it)
+					}
+					last = it.a
+				}
+				sb.Reset()
+				checkerr(t, rb.Dump(&sb, DumpOptions{Slots: true}))
+				runtime.Gosched()
+			}
+			wg.Wait()
+		})
+	}
+}
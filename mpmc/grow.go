@@ -0,0 +1,354 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"sync"
+	"sync/atomic"
+)
+
+// ResizableRingBuffer is a [RingBuffer] whose capacity can be changed
+// while the producers and consumers are running.
+type ResizableRingBuffer[T any] interface {
+	RingBuffer[T]
+
+	// Resize moves the elements into a new backing array of newCap
+	// slots. The producers and consumers wait while the elements are
+	// being moved.
+	//
+	// It returns [ErrInvalidCapacity] if newCap is zero or less than
+	// the quantity of elements, [ErrQueueNotReady] if a slot reserved
+	// or acquired for the zero-copy access is not committed or
+	// released yet, and [ErrQueueClosed] if the ring buffer has been
+	// closed.
+	Resize(newCap uint32) (err error)
+}
+
+// NewGrowable returns a resizable ring buffer based on [NewSequenced],
+// which doubles its capacity automatically rather than returning
+// [ErrQueueFull], till maxCapacity is reached.
+//
+// A maxCapacity not greater than capacity disables the automatic
+// growing, but [ResizableRingBuffer.Resize] can still be called.
+func NewGrowable[T any](capacity, maxCapacity uint32, opts ...Opt[T]) (ringBuffer ResizableRingBuffer[T]) {
+	if !isInitialized() {
+		return
+	}
+	rb, err := newSeqRingBuf(uint64(capacity), opts...)
+	if err != nil {
+		return
+	}
+	g := &growRingBuf[T]{maxCap: maxCapacity, opts: opts, residence: rb.residence.Load()}
+	g.cur.Store(&segment[T]{rb: rb})
+	ringBuffer = g
+	return
+}
+
+// segment is a backing ring buffer of growRingBuf, users counts the
+// producers and consumers which are working on it, and pending counts
+// the slots reserved or acquired by the zero-copy operations.
+type segment[T any] struct {
+	rb      *seqRingBuf[T]
+	users   int32
+	pending int32
+}
+
+// growRingBuf hands over the elements to a new segment on resizing.
+//
+// A producer or consumer registers itself in the users of the current
+// segment before working on it, and backs off while resizing. So once
+// the users drop to zero after resizing is set, no more elements can
+// be put into or taken from the old segment, and they can be moved in
+// order safely. The slots reserved or acquired by the zero-copy
+// operations are pending longer, resizing is given up if there's any.
+type growRingBuf[T any] struct {
+	cur      atomic.Pointer[segment[T]]
+	resizing uint32
+	closed   uint32
+	mu       sync.Mutex // serializes the resizers
+	maxCap   uint32
+	opts     []Opt[T]
+	// residence is shared by the segments, see [WithResidence].
+	residence *histogram
+	notEmpty  notifier
+	notFull   notifier
+}
+
+// enter registers the caller as a user of the current segment, it
+// waits while the ring buffer is being resized.
+func (g *growRingBuf[T]) enter() (s *segment[T]) {
+	for spins := 0; ; spins++ {
+		s = g.cur.Load()
+		atomic.AddInt32(&s.users, 1)
+		if atomic.LoadUint32(&g.resizing) == 0 && g.cur.Load() == s {
+			return
+		}
+		atomic.AddInt32(&s.users, -1)
+		s.rb.idle(spins + 1)
+	}
+}
+
+func (g *growRingBuf[T]) leave(s *segment[T]) { atomic.AddInt32(&s.users, -1) }
+
+// within calls f on the current segment as a user, so that the old
+// segment being drained by resize is never observed.
+func within[T, R any](g *growRingBuf[T], f func(rb *seqRingBuf[T]) R) R {
+	s := g.enter()
+	defer g.leave(s)
+	return f(s.rb)
+}
+
+// grow doubles the capacity of the full segment s, it reports
+// whether the caller should retry.
+func (g *growRingBuf[T]) grow(s *segment[T]) bool {
+	size := s.rb.Cap()
+	if size >= g.maxCap {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cur.Load() != s {
+		return true // resized by another one
+	}
+	return g.resize(uint32(min(uint64(size)*2, uint64(g.maxCap)))) == nil
+}
+
+func (g *growRingBuf[T]) Resize(newCap uint32) (err error) { //nolint:revive
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.resize(newCap)
+}
+
+func (g *growRingBuf[T]) resize(newCap uint32) (err error) {
+	if g.IsClosed() {
+		return ErrQueueClosed
+	}
+	var rb *seqRingBuf[T]
+	if rb, err = newSeqRingBuf(uint64(newCap), g.opts...); err != nil {
+		return
+	}
+
+	old := g.cur.Load()
+	atomic.StoreUint32(&g.resizing, 1)
+	defer func() {
+		atomic.StoreUint32(&g.resizing, 0)
+		g.notFull.broadcast()
+		g.notEmpty.broadcast()
+	}()
+	for spins := 0; atomic.LoadInt32(&old.users) != 0; {
+		spins++
+		old.rb.idle(spins)
+	}
+	// a pending slot might be held for long, even by the caller
+	// itself, so the resizing is given up rather than waiting for it.
+	// No more slots can be reserved or acquired meanwhile.
+	if atomic.LoadInt32(&old.pending) != 0 {
+		return ErrQueueNotReady
+	}
+
+	if old.rb.Size() > rb.Cap() {
+		return ErrInvalidCapacity
+	}
+	// the metrics are handed over to the new segment, the elements
+	// moved are neither put nor taken by the users.
+	m := old.rb.metrics.Load()
+	rb.metrics.Store(nil)
+	// so is the residence histogram, the elements moved keep their
+	// stamps rather than being recorded.
+	h := old.rb.residence.Load()
+	old.rb.residence.Store(nil)
+	rb.residence.Store(nil)
+	var moved uint64
+	for {
+		pos := atomic.LoadUint64(&old.rb.deqPos)
+		it, e := old.rb.Dequeue()
+		if e != nil {
+			if m != nil && errors.Is(e, ErrQueueEmpty) {
+				m.add(statEmpty, ^uint64(0))
+			}
+			break
+		}
+		_ = rb.Enqueue(it)
+		if p := rb.stampOf(rb.slot(moved)); p != nil {
+			*p = *old.rb.stampOf(old.rb.slot(pos))
+		}
+		moved++
+	}
+	if m != nil {
+		m.add(statDequeued, -moved)
+	}
+	rb.metrics.Store(m)
+	rb.residence.Store(h)
+	g.cur.Store(&segment[T]{rb: rb})
+	return
+}
+
+func (g *growRingBuf[T]) Put(item T) (err error) { return g.Enqueue(item) } //nolint:revive
+
+func (g *growRingBuf[T]) Enqueue(item T) (err error) { //nolint:revive
+	for {
+		s := g.enter()
+		err = s.rb.Enqueue(item)
+		g.leave(s)
+		if !errors.Is(err, ErrQueueFull) || !g.grow(s) {
+			break
+		}
+	}
+	if err == nil {
+		g.notEmpty.signal()
+	}
+	return
+}
+
+func (g *growRingBuf[T]) EnqueueBatch(items []T) (n int, err error) { //nolint:revive
+	for {
+		s := g.enter()
+		var k int
+		k, err = s.rb.EnqueueBatch(items[n:])
+		g.leave(s)
+		n += k
+		if !errors.Is(err, ErrQueueFull) || !g.grow(s) {
+			break
+		}
+	}
+	if n > 0 {
+		g.notEmpty.signal()
+	}
+	return
+}
+
+// Reserve claims a free slot for writing in place, see also
+// [ringBuf.Reserve]. The ring buffer can't be resized till the
+// reservation is committed, it doesn't grow but returns
+// [ErrQueueFull] meanwhile.
+func (g *growRingBuf[T]) Reserve() (ptr *T, ticket Ticket, err error) { //nolint:revive
+	for {
+		s := g.enter()
+		ptr, ticket, err = s.rb.Reserve()
+		if err == nil {
+			atomic.AddInt32(&s.pending, 1) // pending till Commit
+		}
+		g.leave(s)
+		if !errors.Is(err, ErrQueueFull) || !g.grow(s) {
+			return
+		}
+	}
+}
+
+func (g *growRingBuf[T]) Commit(ticket Ticket) { //nolint:revive
+	s := g.cur.Load() // cannot be changed while a slot is pending
+	if s.rb.commit(ticket) {
+		atomic.AddInt32(&s.pending, -1)
+		g.notEmpty.signal()
+	}
+}
+
+func (g *growRingBuf[T]) Get() (item T, err error) { return g.Dequeue() } //nolint:revive
+
+func (g *growRingBuf[T]) Dequeue() (item T, err error) { //nolint:revive
+	s := g.enter()
+	item, err = s.rb.Dequeue()
+	g.leave(s)
+	if err == nil {
+		g.notFull.signal()
+	}
+	return
+}
+
+func (g *growRingBuf[T]) DequeueBatch(dst []T) (n int, err error) { //nolint:revive
+	s := g.enter()
+	n, err = s.rb.DequeueBatch(dst)
+	g.leave(s)
+	if n > 0 {
+		g.notFull.signal()
+	}
+	return
+}
+
+// Acquire claims the head element for reading in place, see also
+// [ringBuf.Acquire]. The ring buffer can't be resized till the slot
+// is released, see also Reserve.
+func (g *growRingBuf[T]) Acquire() (ptr *T, ticket Ticket, err error) { //nolint:revive
+	s := g.enter()
+	if ptr, ticket, err = s.rb.Acquire(); err == nil {
+		atomic.AddInt32(&s.pending, 1) // pending till Release
+	}
+	g.leave(s)
+	return
+}
+
+func (g *growRingBuf[T]) Release(ticket Ticket) { //nolint:revive
+	s := g.cur.Load() // cannot be changed while a slot is pending
+	if s.rb.release(ticket) {
+		atomic.AddInt32(&s.pending, -1)
+		g.notFull.signal()
+	}
+}
+
+func (g *growRingBuf[T]) PutCtx(ctx context.Context, item T) (err error) { //nolint:revive
+	return g.EnqueueCtx(ctx, item)
+}
+
+func (g *growRingBuf[T]) EnqueueCtx(ctx context.Context, item T) (err error) { //nolint:revive
+	return enqueueCtx[T](ctx, g, &g.notFull, g.cur.Load().rb.waitStrategy, item)
+}
+
+func (g *growRingBuf[T]) GetCtx(ctx context.Context) (item T, err error) { return g.DequeueCtx(ctx) } //nolint:revive
+
+func (g *growRingBuf[T]) DequeueCtx(ctx context.Context) (item T, err error) { //nolint:revive
+	return dequeueCtx[T](ctx, g, &g.notEmpty, g.cur.Load().rb.waitStrategy)
+}
+
+// Close marks the ring buffer closed, see also [ringBuf.Close].
+func (g *growRingBuf[T]) Close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if atomic.CompareAndSwapUint32(&g.closed, 0, 1) {
+		g.cur.Load().rb.Close()
+		g.notFull.broadcast()
+		g.notEmpty.broadcast()
+	}
+}
+
+func (g *growRingBuf[T]) IsClosed() bool { return atomic.LoadUint32(&g.closed) != 0 }
+
+func (g *growRingBuf[T]) CloseAndDrain() []T { return closeAndDrain[T](g) }
+
+func (g *growRingBuf[T]) Cap() uint32      { return within(g, (*seqRingBuf[T]).Cap) }
+func (g *growRingBuf[T]) CapReal() uint32  { return within(g, (*seqRingBuf[T]).CapReal) }
+func (g *growRingBuf[T]) Size() uint32     { return within(g, (*seqRingBuf[T]).Size) }
+func (g *growRingBuf[T]) Quantity() uint32 { return g.Size() }
+func (g *growRingBuf[T]) IsEmpty() bool    { return within(g, (*seqRingBuf[T]).IsEmpty) }
+
+// IsFull reports whether the ring buffer is full, and it cannot grow
+// anymore.
+func (g *growRingBuf[T]) IsFull() bool {
+	return within(g, func(rb *seqRingBuf[T]) bool { return rb.IsFull() && rb.Cap() >= g.maxCap })
+}
+
+func (g *growRingBuf[T]) String() string { return within(g, (*seqRingBuf[T]).String) }
+
+func (g *growRingBuf[T]) All() iter.Seq[T] { return values(g.Enumerate()) }
+
+// Enumerate walks through the elements without consuming them, see
+// also [ringBuf.Enumerate]. They're copied out before being yielded,
+// so the loop body may resize the ring buffer.
+func (g *growRingBuf[T]) Enumerate() iter.Seq2[uint32, T] {
+	return func(yield func(uint32, T) bool) {
+		o := within(g, func(rb *seqRingBuf[T]) observation[T] { return rb.observe(false) })
+		o.enumerate(yield)
+	}
+}
+
+func (g *growRingBuf[T]) Drain() iter.Seq[T] { return drain[T](g) }
+
+func (g *growRingBuf[T]) Debug(enabled bool) (lastState bool) { //nolint:revive
+	return within(g, func(rb *seqRingBuf[T]) bool { return rb.Debug(enabled) })
+}
+
+func (g *growRingBuf[T]) ResetCounters() { //nolint:revive
+	s := g.enter()
+	defer g.leave(s)
+	s.rb.ResetCounters()
+}
@@ -0,0 +1,224 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGrowable_OneByOne(t *testing.T) {
+	rb := NewGrowable[int](4, 16)
+	defer rb.Close()
+
+	for i := 0; i < 16; i++ {
+		checkerr(t, rb.Enqueue(i))
+	}
+	if rb.Cap() != 16 || !rb.IsFull() {
+		t.Fatalf("expect growing to 16, but cap = %v", rb.Cap())
+	}
+	if err := rb.Enqueue(16); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expect ErrQueueFull but got %v", err)
+	}
+
+	if err := rb.Resize(8); !errors.Is(err, ErrInvalidCapacity) {
+		t.Fatalf("expect ErrInvalidCapacity but got %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		if it, err := rb.Dequeue(); err != nil || it != i {
+			t.Fatalf("expect %v but got %v, err: %v", i, it, err)
+		}
+	}
+	checkerr(t, rb.Resize(8))
+	if rb.Cap() != 8 || fmt.Sprint(rb) != "[10,11,12,13,14,15,]/6" {
+		t.Fatalf("expect shrinking to 8, but got %v/%v", rb, rb.Cap())
+	}
+
+	n, err := rb.EnqueueBatch([]int{16, 17, 18, 19, 20, 21, 22, 23, 24, 25})
+	if err != nil || n != 10 || rb.Cap() != 16 || rb.Size() != 16 {
+		t.Fatalf("expect 10 items put by growing, but got %v, err: %v, cap: %v", n, err, rb.Cap())
+	}
+
+	rb.Close()
+	if err = rb.Resize(32); !errors.Is(err, ErrQueueClosed) {
+		t.Fatalf("expect ErrQueueClosed but got %v", err)
+	}
+	if items := rb.CloseAndDrain(); len(items) != 16 || items[0] != 10 || items[15] != 25 {
+		t.Fatalf("unexpected elements: %v", items)
+	}
+}
+
+func TestGrowable_Concurrent(t *testing.T) {
+	const producers, consumers, cnt = 4, 4, 20000
+
+	rb := NewGrowable[int](4, 64)
+	defer rb.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var wg, resizer sync.WaitGroup
+	var sum, got int64
+	stop := make(chan struct{})
+	resizer.Add(1)
+	go func() {
+		defer resizer.Done()
+		for i := uint32(0); ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			_ = rb.Resize(8 << (i % 4))
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	for i := 0; i < producers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 1; j <= cnt; j++ {
+				if err := rb.EnqueueCtx(ctx, j); err != nil {
+					t.Errorf("[PUT] failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < consumers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.AddInt64(&got, 1) <= producers*cnt {
+				it, err := rb.DequeueCtx(ctx)
+				if err != nil {
+					t.Errorf("[GET] failed: %v", err)
+					return
+				}
+				atomic.AddInt64(&sum, int64(it))
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	resizer.Wait()
+
+	if expect := int64(producers * cnt * (cnt + 1) / 2); sum != expect {
+		t.Fatalf("expect sum %v but got %v", expect, sum)
+	}
+}
+
+func TestGrowable_Order(t *testing.T) {
+	const cnt = 50000
+
+	rb := NewGrowable[int](2, 1024)
+	defer rb.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < cnt; i++ {
+			if it, err := rb.DequeueCtx(ctx); err != nil || it != i {
+				t.Errorf("expect %v but got %v, err: %v", i, it, err)
+				return
+			}
+			if i%1000 == 0 {
+				_ = rb.Resize(uint32(rb.Size()) + 4)
+			}
+		}
+	}()
+	for i := 0; i < cnt; i++ {
+		checkerr(t, rb.EnqueueCtx(ctx, i))
+	}
+	<-done
+}
+
+func TestGrowable_ReadWhileResizing(t *testing.T) {
+	const cnt = 1000
+
+	rb := NewGrowable[int](1024, 4096)
+	defer rb.Close()
+	for i := 0; i < cnt; i++ {
+		checkerr(t, rb.Enqueue(i))
+	}
+
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; !stop.Load(); i++ {
+			checkerr(t, rb.Resize(uint32(1024<<(i%2))))
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		if n := rb.Size(); n != cnt || rb.IsEmpty() {
+			t.Errorf("#%v: expect %v elements while resizing, but got %v", i, cnt, n)
+			break
+		}
+		if s := rb.Snapshot(); len(s) != cnt {
+			t.Errorf("#%v: expect %v elements while resizing, but got %v", i, cnt, len(s))
+			break
+		}
+		if it, err := rb.Peek(); err != nil || it != 0 {
+			t.Errorf("#%v: expect the head 0 while resizing, but got %v, err: %v", i, it, err)
+			break
+		}
+	}
+	stop.Store(true)
+	wg.Wait()
+}
+
+// TestGrowable_ReservePending fills the ring buffer while a slot is
+// reserved, it can't grow, but must not wait for the reservation,
+// which is held by the caller itself.
+func TestGrowable_ReservePending(t *testing.T) {
+	rb := NewGrowable[int](4, 64)
+	defer rb.Close()
+
+	ptr, ticket, err := rb.Reserve()
+	checkerr(t, err)
+	for i := 1; i < 4; i++ {
+		checkerr(t, rb.Enqueue(i))
+	}
+
+	done := make(chan error)
+	go func() { done <- rb.Enqueue(4) }()
+	select {
+	case err = <-done:
+		if !errors.Is(err, ErrQueueFull) {
+			t.Fatalf("expect ErrQueueFull while reserved, but got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Enqueue hangs on the pending reservation")
+	}
+	if err = rb.Resize(16); !errors.Is(err, ErrQueueNotReady) {
+		t.Fatalf("expect ErrQueueNotReady while reserved, but got %v", err)
+	}
+
+	*ptr = 0
+	rb.Commit(ticket)
+	checkerr(t, rb.Enqueue(4))
+	if rb.Cap() != 8 {
+		t.Fatalf("expect grown to 8 once committed, but got %v", rb.Cap())
+	}
+	_, ticket, err = rb.Acquire()
+	checkerr(t, err)
+	if err = rb.Resize(16); !errors.Is(err, ErrQueueNotReady) {
+		t.Fatalf("expect ErrQueueNotReady while acquired, but got %v", err)
+	}
+	rb.Release(ticket)
+	checkerr(t, rb.Resize(16))
+	for i := 1; i <= 4; i++ {
+		if it, err := rb.Dequeue(); err != nil || it != i {
+			t.Fatalf("expect %v but got %v, err: %v", i, it, err)
+		}
+	}
+}
@@ -1,5 +1,11 @@
 package mpmc
 
+import (
+	"context"
+	"io"
+	"iter"
+)
+
 // Queue interface provides a set of standard queue operations
 type Queue[T any] interface {
 	Enqueue(item T) (err error)
@@ -28,28 +34,118 @@ type RichOverlappedRingBuffer[T any] interface {
 	// current capacity of container.
 	// If error occurred, both of these two fields are undefined.
 	EnqueueMRich(item T) (size, overwrites uint32, err error)
+	// EnqueueBatchM puts all items and returns how many elements
+	// were overwritten, just like calling [EnqueueM] one by one.
+	EnqueueBatchM(items []T) (overwrites uint32, err error)
 }
 
 // RingBuffer interface provides a set of standard ring buffer operations
 type RingBuffer[T any] interface {
+	// Close marks the ring buffer closed, see also [ErrQueueClosed].
 	Close()
+	IsClosed() bool
+	// CloseAndDrain closes the ring buffer and returns the remaining
+	// elements.
+	CloseAndDrain() []T
 
 	// Queue[T]
 
 	Enqueue(item T) (err error)   // or [Put] as alternative
 	Dequeue() (item T, err error) // or [Get] as alternative
 	Cap() uint32                  // Cap returns the outer capacity of the ring buffer.
-	CapReal() uint32              // CapReal returns the real (inner) capacity of the ring buffer.
-	Size() uint32                 // Size returns the quantity of items in the ring buffer queue
+	CapReal() uint32              // CapReal returns the maximal number of elements.
+	// Size returns the quantity of items in the ring buffer queue.
+	// It's always in the range [0, CapReal()], and it held at some
+	// moment during the call unless under heavy contention. IsEmpty
+	// and IsFull are consistent with it.
+	Size() uint32
 	IsEmpty() (empty bool)
 	IsFull() (full bool)
+	// Reset clears the ring buffer, it's safe while the producers and
+	// consumers are running, which see [ErrQueueNotReady] meanwhile.
 	Reset()
+	// Clear is like Reset, and returns the elements removed.
+	Clear() []T
+	// ClearFunc is like Reset, and passes the elements removed to f.
+	ClearFunc(f func(T))
 
 	Put(item T) (err error)
 	Get() (item T, err error)
 
+	// EnqueueCtx blocks while the ring buffer is full, till a slot
+	// is freed or ctx is done. In the latter case, ctx.Err() will
+	// be returned.
+	EnqueueCtx(ctx context.Context, item T) (err error) // or [PutCtx] as alternative
+	// DequeueCtx blocks while the ring buffer is empty, till an
+	// element arrives or ctx is done. In the latter case, ctx.Err()
+	// will be returned.
+	DequeueCtx(ctx context.Context) (item T, err error) // or [GetCtx] as alternative
+
+	PutCtx(ctx context.Context, item T) (err error)
+	GetCtx(ctx context.Context) (item T, err error)
+
+	// EnqueueBatch puts items with only one index reservation. It
+	// returns how many leading items were put, and [ErrQueueFull]
+	// if that is less than len(items).
+	EnqueueBatch(items []T) (n int, err error)
+	// DequeueBatch takes up to len(dst) elements into dst with only
+	// one index reservation. It returns how many elements were
+	// taken, and [ErrQueueEmpty] if nothing could be taken.
+	DequeueBatch(dst []T) (n int, err error)
+
+	// DequeueIf takes the head element only if pred reports true for
+	// it, otherwise ok is false and the element is kept. The element
+	// cannot be taken by another consumer while pred is being called.
+	DequeueIf(pred func(T) bool) (item T, ok bool, err error)
+	// DequeueWhile takes the leading elements into dst while pred
+	// reports true for them, and returns how many were taken.
+	DequeueWhile(pred func(T) bool, dst []T) (n int, err error)
+
+	// Reserve claims a free slot and returns a pointer to write the
+	// element in place, without copying it. The element is invisible
+	// to the consumers till Commit is called with the ticket.
+	Reserve() (ptr *T, ticket Ticket, err error)
+	Commit(ticket Ticket)
+	// Acquire claims the head element and returns a pointer to read
+	// it in place, without copying it. The slot won't be reused till
+	// Release is called with the ticket.
+	Acquire() (ptr *T, ticket Ticket, err error)
+	Release(ticket Ticket)
+
+	// Peek returns the head element without consuming it. A slot
+	// being written is never observed, and the element might have
+	// been taken by a consumer once Peek returns.
+	Peek() (item T, err error)
+	// PeekAt is like Peek, and returns the element at the logical
+	// position i, the head element is at position 0.
+	PeekAt(i uint32) (item T, err error)
+	// PeekN copies the leading elements into dst without consuming
+	// them, and returns how many were copied.
+	PeekN(dst []T) int
+
+	// All walks through the elements from head to tail without
+	// consuming them.
+	All() iter.Seq[T]
+	// Enumerate is like All, and yields the logical positions too,
+	// the head element is at position 0.
+	Enumerate() iter.Seq2[uint32, T]
+	// Drain dequeues the elements as it yields them, till the ring
+	// buffer is empty or the loop breaks.
+	Drain() iter.Seq[T]
+
+	// Snapshot returns the elements from head to tail without
+	// consuming them, and without blocking on the slots in flight.
+	Snapshot() []T
+	// Dump writes the elements, and optionally the slot states and
+	// the counters, to w without blocking. It's for diagnostics.
+	Dump(w io.Writer, opts DumpOptions) error
+
 	Quantity() uint32 // Quantity returns the quantity of items in the ring buffer queue
 
+	// Stats returns the metrics collected since [WithMetrics] or
+	// Debug(true), and the current size.
+	Stats() Stats
+
 	Debug(enabled bool) (lastState bool) // for internal debugging, see [Dbg] interface.
 	ResetCounters()                      // for internal debugging, see [Dbg] interface.
 }
@@ -17,6 +17,8 @@ func init() { //nolint:gochecknoinits
 		ErrQueueEmpty = errors.New("queue empty")
 		ErrRaced = errors.New("queue race")
 		ErrQueueNotReady = errors.New("queue not ready")
+		ErrQueueClosed = errors.New("queue closed")
+		ErrInvalidCapacity = errors.New("invalid capacity")
 		atomic.CompareAndSwapUint32(&initialized, 0, 1)
 	})
 }
@@ -41,6 +43,13 @@ var ErrRaced error
 // ErrQueueNotReady queue not ready for enqueue or dequeue
 var ErrQueueNotReady error
 
+// ErrQueueClosed queue closed when enqueueing, or queue closed
+// and drained when dequeueing
+var ErrQueueClosed error
+
+// ErrInvalidCapacity the requested capacity is zero or too large
+var ErrInvalidCapacity error
+
 // CacheLinePadSize represents the CPU Cache Line Padding Size, compliant with the current running CPU Architect
 const CacheLinePadSize = unsafe.Sizeof(cpu.CacheLinePad{})
 
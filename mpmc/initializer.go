@@ -1,6 +1,10 @@
 package mpmc
 
 // Initializeable data item supports lighter-weight clone operations.
+//
+// PreAlloc is called for each slot at construction and after Reset,
+// so the slot buffers, such as []byte, can be allocated once and
+// reused by CloneIn copying into them.
 type Initializeable[T any] interface {
 	PreAlloc(index int) (newBlock T)
 	CloneIn(srcBlock T, targetBlock *T)
@@ -111,3 +111,62 @@ func TestCustomItem(t *testing.T) {
 		t.Fatal("expect empty event")
 	}
 }
+
+// bufInit pre-allocates a fixed-size buffer for each slot, and
+// copies the elements into and out of it.
+type bufInit struct {
+	size   int
+	allocs int
+}
+
+func (s *bufInit) PreAlloc(int) (newBlock []byte) {
+	s.allocs++
+	return make([]byte, 0, s.size)
+}
+
+func (s *bufInit) CloneIn(srcBlock []byte, targetBlock *[]byte) {
+	*targetBlock = append((*targetBlock)[:0], srcBlock...)
+}
+
+func (s *bufInit) CloneOut(srcBlock *[]byte) (targetBlock []byte) {
+	return *srcBlock
+}
+
+func TestPreAlloc(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		create func(capacity uint32, opts ...Opt[[]byte]) RingBuffer[[]byte]
+	}{
+		{"MPMC", New[[]byte]},
+		{"SPSC", NewSPSC[[]byte]},
+		{"Sequenced", NewSequenced[[]byte]},
+		{"Overlapped", func(capacity uint32, opts ...Opt[[]byte]) RingBuffer[[]byte] {
+			return NewOverlappedRingBuffer(capacity, opts...)
+		}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			bi := &bufInit{size: 64}
+			rb := c.create(8, WithItemInitializer[[]byte](bi))
+			defer rb.Close()
+			if bi.allocs != 8 {
+				t.Fatalf("expect 8 slots pre-allocated but got %v", bi.allocs)
+			}
+
+			src := []byte("packet")
+			allocs := testing.AllocsPerRun(100, func() {
+				checkerr(t, rb.Enqueue(src))
+				if it, err := rb.Dequeue(); err != nil || string(it) != "packet" {
+					t.Fatalf("expect %q but got %q, err: %v", src, it, err)
+				}
+			})
+			if allocs != 0 {
+				t.Fatalf("expect no allocations but got %v", allocs)
+			}
+
+			rb.Reset()
+			if bi.allocs != 16 {
+				t.Fatalf("expect 8 slots pre-allocated again but got %v", bi.allocs-8)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package mpmc
+
+// LargeRingBuffer is a [RingBuffer] addressed by the 64-bit positions,
+// which supports more than 2^32 slots on 64-bit hosts, and reports
+// the total counts of the elements passed through it.
+//
+// Cap, CapReal and Size are clamped to MaxUint32, use Cap64 and
+// Size64 for the exact values.
+type LargeRingBuffer[T any] interface {
+	RingBuffer[T]
+
+	Cap64() uint64  // Cap64 returns the number of slots, all of them are usable
+	Size64() uint64 // Size64 returns the quantity of elements
+	// Enqueued returns the total number of elements enqueued since
+	// the creation or the last Reset.
+	Enqueued() uint64
+	// Dequeued returns the total number of elements dequeued since
+	// the creation or the last Reset.
+	Dequeued() uint64
+}
+
+// TryNew is like [New], but it validates the capacity before
+// allocating, and returns [ErrInvalidCapacity] for a capacity which
+// cannot hold an element, i.e. less than 2 (or zero with
+// [WithExactCapacity]), or which cannot be rounded up to a power of 2
+// in uint32, i.e. greater than 2^31 without [WithExactCapacity].
+func TryNew[T any](capacity uint32, opts ...Opt[T]) (ringBuffer RingBuffer[T], err error) {
+	if !isInitialized() {
+		err = ErrQueueNotReady
+		return
+	}
+	var probe ringBuf[T] // resolves the options only
+	for _, opt := range opts {
+		opt(&probe)
+	}
+	if probe.exactCapacity {
+		var rb *seqRingBuf[T]
+		if rb, err = newSeqRingBuf(uint64(capacity), opts...); err == nil {
+			ringBuffer = rb
+		}
+		return
+	}
+	if capacity < 2 || capacity > 1<<31 {
+		err = ErrInvalidCapacity
+		return
+	}
+	ringBuffer = New(capacity, opts...)
+	return
+}
+
+// NewLarge returns a sequenced MPMC ring buffer, see [NewSequenced],
+// with a 64-bit capacity.
+//
+// The capacity is rounded up to a power of 2, unless
+// [WithExactCapacity] is specified. [ErrInvalidCapacity] will be
+// returned if it's zero, or it exceeds the addressable memory.
+func NewLarge[T any](capacity uint64, opts ...Opt[T]) (ringBuffer LargeRingBuffer[T], err error) {
+	if !isInitialized() {
+		err = ErrQueueNotReady
+		return
+	}
+	var rb *seqRingBuf[T]
+	if rb, err = newSeqRingBuf(capacity, opts...); err == nil {
+		ringBuffer = rb
+	}
+	return
+}
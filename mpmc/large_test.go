@@ -0,0 +1,97 @@
+package mpmc
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestTryNew(t *testing.T) {
+	for _, c := range []struct {
+		capacity uint32
+		opts     []Opt[int]
+		cap      uint32
+	}{
+		{0, nil, 0},
+		{1, nil, 0},
+		{2, nil, 2},
+		{1<<31 + 1, nil, 0},
+		{math.MaxUint32, nil, 0},
+		{0, []Opt[int]{WithExactCapacity[int]()}, 0},
+		{80, nil, 128},
+		{80, []Opt[int]{WithExactCapacity[int]()}, 80},
+		{1, []Opt[int]{WithExactCapacity[int]()}, 1},
+	} {
+		rb, err := TryNew(c.capacity, c.opts...)
+		if c.cap == 0 {
+			if !errors.Is(err, ErrInvalidCapacity) || rb != nil {
+				t.Fatalf("expect ErrInvalidCapacity for %v but got %v", c.capacity, err)
+			}
+			continue
+		}
+		checkerr(t, err)
+		if rb.Cap() != c.cap {
+			t.Fatalf("expect cap %v but got %v", c.cap, rb.Cap())
+		}
+	}
+}
+
+func TestNewLarge(t *testing.T) {
+	for _, capacity := range []uint64{0, 1 << 62, 1<<62 - 1, 1<<63 + 1, math.MaxUint64} {
+		if _, err := NewLarge[int](capacity); !errors.Is(err, ErrInvalidCapacity) {
+			t.Fatalf("expect ErrInvalidCapacity for %v but got %v", capacity, err)
+		}
+	}
+	// not allocatable, though the slice length doesn't overflow.
+	for _, opts := range [][]Opt[int]{
+		{WithExactCapacity[int]()},
+		{WithExactCapacity[int](), WithCompactLayout[int]()},
+		{WithExactCapacity[int](), WithCompactLayout[int](), WithResidence[int]()},
+	} {
+		var probe ringBuf[int]
+		for _, opt := range opts {
+			opt(&probe)
+		}
+		probe.setup()
+		capacity := maxSlots[int](probe.shift, probe.residence.Load() != nil) + 1
+		if _, err := NewLarge(capacity, opts...); !errors.Is(err, ErrInvalidCapacity) {
+			t.Fatalf("expect ErrInvalidCapacity for %v but got %v", capacity, err)
+		}
+	}
+
+	rb, err := NewLarge[int](100)
+	checkerr(t, err)
+	if rb.Cap64() != 128 || rb.Cap() != 128 {
+		t.Fatalf("expect cap 128 but got %v", rb.Cap64())
+	}
+
+	for lap := 0; lap < 10; lap++ {
+		for i := 0; i < 20; i++ {
+			checkerr(t, rb.Enqueue(i))
+		}
+		for i := 0; i < 10; i++ {
+			if _, err = rb.Dequeue(); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+		}
+	}
+	if rb.Enqueued() != 200 || rb.Dequeued() != 100 || rb.Size64() != 100 {
+		t.Fatalf("expect 200 enqueued and 100 dequeued, but got %v, %v, size %v", rb.Enqueued(), rb.Dequeued(), rb.Size64())
+	}
+
+	rb.Reset()
+	if rb.Enqueued() != 0 || rb.Dequeued() != 0 || !rb.IsEmpty() {
+		t.Fatalf("expect the counters reset, but got %v, %v", rb.Enqueued(), rb.Dequeued())
+	}
+}
+
+func TestRoundUpToPower2x64(t *testing.T) {
+	for v, expect := range map[uint64]uint64{
+		0: 0, 1: 1, 2: 2, 3: 4, 80: 128, 1 << 32: 1 << 32, 1<<32 + 1: 1 << 33,
+		1 << 63: 1 << 63, 1<<63 + 1: 0, math.MaxUint64: 0,
+	} {
+		if got := roundUpToPower2x64(v); got != expect {
+			t.Fatalf("roundUpToPower2x64(%v) = %v, expect %v", v, got, expect)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package mpmc
+
+import (
+	"fmt"
+	"testing"
+	"unsafe"
+)
+
+func TestCompactLayout(t *testing.T) {
+	for _, c := range topologies {
+		for _, compact := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/compact=%v", c.name, compact), func(t *testing.T) {
+				var opts []Opt[int]
+				if compact {
+					opts = append(opts, WithCompactLayout[int]())
+				}
+				rb := c.create(8, opts...)
+				defer rb.Close()
+
+				if perSlot := slotBytes(rb); compact && perSlot != unsafe.Sizeof(rbItem[int]{}) ||
+					!compact && perSlot < CacheLinePadSize {
+					t.Fatalf("unexpected %v bytes per slot", perSlot)
+				}
+
+				for lap := 0; lap < 3; lap++ {
+					for i := 0; i < 5; i++ {
+						checkerr(t, rb.Enqueue(i))
+					}
+					for i := 0; i < 5; i++ {
+						if it, err := rb.Dequeue(); err != nil || it != i {
+							t.Fatalf("expect %v but got %v, err: %v", i, it, err)
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+// slotBytes returns the memory footprint of each slot.
+func slotBytes[T any](rb RingBuffer[T]) uintptr {
+	var data []rbItem[T]
+	switch x := rb.(type) {
+	case *ringBuf[T]:
+		data = x.data
+	case *topoRingBuf[T]:
+		data = x.data
+	case *seqRingBuf[T]:
+		data = x.data
+	}
+	return uintptr(len(data)) * unsafe.Sizeof(rbItem[T]{}) / uintptr(rb.Cap())
+}
+
+// go test ./mpmc -bench 'Layout' -benchmem -run=none
+//
+// The B/op of the New benchmarks is the memory footprint of a ring
+// buffer of 1M uint32 elements.
+func BenchmarkLayout_NewPadded(b *testing.B) { newLayout(b) }
+
+func BenchmarkLayout_NewCompact(b *testing.B) { newLayout(b, WithCompactLayout[uint32]()) }
+
+func BenchmarkLayout_PipePadded(b *testing.B) { pipe(b, New[int](1024)) }
+
+func BenchmarkLayout_PipeCompact(b *testing.B) { pipe(b, New[int](1024, WithCompactLayout[int]())) }
+
+func BenchmarkLayout_ParallelPadded(b *testing.B) { parallel(b, New[int](1024)) }
+
+func BenchmarkLayout_ParallelCompact(b *testing.B) {
+	parallel(b, New[int](1024, WithCompactLayout[int]()))
+}
+
+func newLayout(b *testing.B, opts ...Opt[uint32]) {
+	b.ReportAllocs()
+	var rb RingBuffer[uint32]
+	for i := 0; i < b.N; i++ {
+		rb = New[uint32](1<<20, opts...)
+	}
+	b.ReportMetric(float64(slotBytes(rb)), "B/slot")
+}
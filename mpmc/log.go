@@ -0,0 +1,110 @@
+package mpmc
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// WithLogger logs the notable events of the ring buffer by logger,
+// so that the diagnostics can be turned on at runtime, without the
+// verbose build tag:
+//
+//   - full: an enqueue was rejected with [ErrQueueFull], at
+//     [slog.LevelDebug];
+//   - overwrite: the overlapped ring buffer overwrote its head
+//     elements, at [slog.LevelDebug];
+//   - raced: [ErrRaced] happened, at [slog.LevelError];
+//   - not-ready: an operation was rejected with [ErrQueueNotReady],
+//     at [slog.LevelWarn];
+//   - close: the ring buffer was closed, at [slog.LevelInfo].
+//
+// An event is logged only if its level is enabled by logger, and the
+// frequent ones are sampled, see [WithLogSampling]. Use logger.With
+// to tell the ring buffers apart.
+func WithLogger[T any](logger *slog.Logger) Opt[T] {
+	return func(buf *ringBuf[T]) {
+		buf.logger = nil
+		if logger != nil {
+			buf.logger = &eventLogger{logger: logger}
+		}
+	}
+}
+
+// WithLogSampling logs the first occurrence of each event, and then
+// every n-th one, with the occurrences counted so far. The default
+// is [DefaultLogSampling], and 1 logs every occurrence.
+func WithLogSampling[T any](n uint64) Opt[T] {
+	return func(buf *ringBuf[T]) {
+		buf.logEvery = n
+	}
+}
+
+// DefaultLogSampling is the default sampling interval of the events,
+// see [WithLogSampling].
+const DefaultLogSampling = 1000
+
+type logEvent int
+
+const (
+	evFull logEvent = iota
+	evOverwrite
+	evRaced
+	evNotReady
+	evClose
+	evCount
+)
+
+var eventNames = [evCount]string{"full", "overwrite", "raced", "not-ready", "close"}
+
+var eventLevels = [evCount]slog.Level{slog.LevelDebug, slog.LevelDebug, slog.LevelError, slog.LevelWarn, slog.LevelInfo}
+
+// eventLogger counts the occurrences of each event for sampling.
+type eventLogger struct {
+	logger *slog.Logger
+	seen   [evCount]uint64
+}
+
+// log logs the n-th occurrence of ev if it's sampled.
+func (l *eventLogger) log(ev logEvent, every uint64, attrs ...slog.Attr) {
+	n := atomic.AddUint64(&l.seen[ev], 1)
+	if every == 0 {
+		every = DefaultLogSampling
+	}
+	if n != 1 && n%every != 0 {
+		return
+	}
+	ctx := context.Background()
+	if !l.logger.Enabled(ctx, eventLevels[ev]) {
+		return
+	}
+	attrs = append(attrs, slog.String("event", eventNames[ev]), slog.Uint64("occurrences", n))
+	l.logger.LogAttrs(ctx, eventLevels[ev], "ringbuf: "+eventNames[ev], attrs...)
+}
+
+// event logs ev if [WithLogger] is specified.
+func (rb *ringBuf[T]) event(ev logEvent) {
+	if l := rb.logger; l != nil {
+		l.log(ev, rb.logEvery, slog.Uint64("cap", uint64(rb.cap)))
+	}
+}
+
+// raced returns [ErrRaced], and logs it.
+func (rb *ringBuf[T]) raced() error {
+	rb.event(evRaced)
+	return ErrRaced
+}
+
+// notReady returns [ErrQueueNotReady], and logs it.
+func (rb *ringBuf[T]) notReady() error {
+	rb.event(evNotReady)
+	return ErrQueueNotReady
+}
+
+// overwritten counts and logs n elements overwritten.
+func (rb *ringBuf[T]) overwritten(n uint64) {
+	if n != 0 {
+		rb.count(statOverwrites, n)
+		rb.event(evOverwrite)
+	}
+}
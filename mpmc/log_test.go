@@ -0,0 +1,67 @@
+package mpmc
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWithLogger(t *testing.T) {
+	var sb strings.Builder
+	logger := slog.New(slog.NewTextHandler(&sb, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	rb := New(4, WithLogger[int](logger), WithLogSampling[int](2))
+	for i := 0; i < 3; i++ {
+		checkerr(t, rb.Enqueue(i))
+	}
+	for i := 0; i < 4; i++ {
+		if err := rb.Enqueue(i); !errors.Is(err, ErrQueueFull) {
+			t.Fatalf("expect ErrQueueFull but got %v", err)
+		}
+	}
+	// the 1st, 2nd and 4th occurrences are sampled.
+	if n := strings.Count(sb.String(), "event=full"); n != 3 || !strings.Contains(sb.String(), "occurrences=4") {
+		t.Fatalf("expect 3 sampled events, but got %v:\n%v", n, sb.String())
+	}
+
+	atomic.StoreUint32(&rb.(*ringBuf[int]).resetting, 1)
+	if _, err := rb.Dequeue(); !errors.Is(err, ErrQueueNotReady) {
+		t.Fatalf("expect ErrQueueNotReady but got %v", err)
+	}
+	atomic.StoreUint32(&rb.(*ringBuf[int]).resetting, 0)
+	rb.Close()
+	for _, s := range []string{"level=WARN msg=\"ringbuf: not-ready\"", "level=INFO msg=\"ringbuf: close\""} {
+		if !strings.Contains(sb.String(), s) {
+			t.Fatalf("expect %q in:\n%v", s, sb.String())
+		}
+	}
+
+	sb.Reset()
+	orb := NewOverlappedRingBuffer(4, WithLogger[int](logger.With("ring", "orb")))
+	defer orb.Close()
+	for i := 0; i < 5; i++ {
+		checkerr(t, orb.Enqueue(i))
+	}
+	if n := strings.Count(sb.String(), "event=overwrite"); n != 1 || !strings.Contains(sb.String(), "ring=orb") {
+		t.Fatalf("expect an overwrite event of orb, but got:\n%v", sb.String())
+	}
+}
+
+func TestWithLogger_Level(t *testing.T) {
+	var sb strings.Builder
+	logger := slog.New(slog.NewTextHandler(&sb, nil)) // info
+
+	rb := NewSequenced(2, WithLogger[int](logger), WithLogSampling[int](1))
+	for i := 0; i < 3; i++ {
+		_ = rb.Enqueue(i)
+	}
+	if sb.Len() != 0 {
+		t.Fatalf("expect the debug events suppressed, but got:\n%v", sb.String())
+	}
+	rb.Close()
+	if !strings.Contains(sb.String(), "event=close") {
+		t.Fatalf("expect the close event, but got:\n%v", sb.String())
+	}
+}
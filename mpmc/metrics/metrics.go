@@ -0,0 +1,214 @@
+// Package metrics exports the statistics of the named ring buffers,
+// as the expvar variables and in the Prometheus text exposition
+// format, without depending on the Prometheus client.
+//
+// The ring buffers should be created with [mpmc.WithMetrics], or else
+// only the size and capacity are exported. The residence time is
+// exported with [mpmc.WithResidence].
+//
+//	rb := mpmc.New[int](1024, mpmc.WithMetrics[int]())
+//	_ = metrics.Register("events", rb)
+//	metrics.Publish("ringbuf")
+//	http.Handle("/metrics", metrics.Handler())
+package metrics
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hedzr/go-ringbuf/v2/mpmc"
+)
+
+// Source is a ring buffer whose statistics can be exported, all of
+// [mpmc.RingBuffer] implement it.
+type Source interface {
+	Stats() mpmc.Stats
+}
+
+// ErrDuplicateName is returned if a name has been registered.
+var ErrDuplicateName = errors.New("duplicate ring buffer name")
+
+// Registry holds the named ring buffers. The zero value is ready to
+// use.
+type Registry struct {
+	mu    sync.RWMutex
+	rings map[string]Source
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry { return &Registry{} }
+
+// Default is the registry used by the package-level functions.
+var Default = NewRegistry()
+
+// Register adds src as name, which is the value of the ring label.
+func (r *Registry) Register(name string, src Source) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.rings[name]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateName, name)
+	}
+	if r.rings == nil {
+		r.rings = make(map[string]Source)
+	}
+	r.rings[name] = src
+	return nil
+}
+
+// Unregister removes the ring buffer registered as name.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.rings, name)
+}
+
+// Stats returns the statistics of each ring buffer by name.
+func (r *Registry) Stats() map[string]mpmc.Stats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m := make(map[string]mpmc.Stats, len(r.rings))
+	for name, src := range r.rings {
+		m[name] = src.Stats()
+	}
+	return m
+}
+
+// Var returns an expvar variable which shows the statistics of each
+// ring buffer by name, as a JSON object.
+func (r *Registry) Var() expvar.Var {
+	return expvar.Func(func() any { return r.Stats() })
+}
+
+// Publish publishes the statistics as the expvar variable name. Like
+// [expvar.Publish], it panics if name has been published.
+func (r *Registry) Publish(name string) { expvar.Publish(name, r.Var()) }
+
+// ContentType is the media type of the Prometheus text exposition
+// format written by [Registry.WritePrometheus].
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// family describes a metric family of the Prometheus exposition,
+// each series is labelled by ring, and optionally by label.
+type family struct {
+	name, typ, help string
+	series          []series
+}
+
+type series struct {
+	label string // the extra label, such as `reason="full"`
+	value func(s *mpmc.Stats) uint64
+}
+
+func single(value func(s *mpmc.Stats) uint64) []series { return []series{{value: value}} }
+
+var families = []family{
+	{"ringbuf_size", "gauge", "The quantity of elements in the ring buffer.",
+		single(func(s *mpmc.Stats) uint64 { return s.Size })},
+	{"ringbuf_capacity", "gauge", "The real capacity of the ring buffer.",
+		single(func(s *mpmc.Stats) uint64 { return s.Cap })},
+	{"ringbuf_high_water", "gauge", "The maximal size observed after an enqueue.",
+		single(func(s *mpmc.Stats) uint64 { return s.HighWater })},
+	{"ringbuf_enqueued_total", "counter", "The elements put into the ring buffer.",
+		single(func(s *mpmc.Stats) uint64 { return s.Enqueued })},
+	{"ringbuf_dequeued_total", "counter", "The elements taken from the ring buffer.",
+		single(func(s *mpmc.Stats) uint64 { return s.Dequeued })},
+	{"ringbuf_overwrites_total", "counter", "The elements overwritten by the overlapped ring buffer.",
+		single(func(s *mpmc.Stats) uint64 { return s.Overwrites })},
+	{"ringbuf_rejections_total", "counter", "The operations rejected since the ring buffer was full or empty.", []series{
+		{`reason="full"`, func(s *mpmc.Stats) uint64 { return s.FullRejections }},
+		{`reason="empty"`, func(s *mpmc.Stats) uint64 { return s.EmptyRejections }},
+	}},
+	{"ringbuf_cas_retries_total", "counter", "The head or tail CAS retried.",
+		single(func(s *mpmc.Stats) uint64 { return s.CASRetries })},
+	{"ringbuf_yields_total", "counter", "The waits in the lock-free retry loops.",
+		single(func(s *mpmc.Stats) uint64 { return s.Yields })},
+}
+
+// WritePrometheus writes the statistics in the Prometheus text
+// exposition format, labelled by ring, the name registered.
+func (r *Registry) WritePrometheus(w io.Writer) (err error) {
+	stats := r.Stats()
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var sb strings.Builder
+	for _, f := range families {
+		_, _ = fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.typ)
+		for _, x := range f.series {
+			for _, name := range names {
+				s := stats[name]
+				_, _ = fmt.Fprintf(&sb, "%s{ring=\"%s\"", f.name, escape(name))
+				if x.label != "" {
+					_, _ = sb.WriteString("," + x.label)
+				}
+				_, _ = fmt.Fprintf(&sb, "} %d\n", x.value(&s))
+			}
+		}
+	}
+	writeResidence(&sb, names, stats)
+	_, err = io.WriteString(w, sb.String())
+	return
+}
+
+// writeResidence writes [mpmc.Stats].Residence as a summary in
+// seconds, and its maximum as a gauge.
+func writeResidence(sb *strings.Builder, names []string, stats map[string]mpmc.Stats) {
+	const name = "ringbuf_residence_seconds"
+	_, _ = fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s summary\n", name, "The time the elements stayed in the ring buffer.", name)
+	for _, ring := range names {
+		r, label := stats[ring].Residence, escape(ring)
+		for _, q := range []struct {
+			quantile string
+			value    time.Duration
+		}{{"0.5", r.P50}, {"0.99", r.P99}, {"0.999", r.P999}} {
+			_, _ = fmt.Fprintf(sb, "%s{ring=\"%s\",quantile=\"%s\"} %s\n", name, label, q.quantile, seconds(q.value))
+		}
+		_, _ = fmt.Fprintf(sb, "%s_sum{ring=\"%s\"} %s\n%s_count{ring=\"%s\"} %d\n", name, label, seconds(r.Sum), name, label, r.Count)
+	}
+	const maxName = "ringbuf_residence_max_seconds"
+	_, _ = fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s gauge\n", maxName, "The maximal time an element stayed in the ring buffer.", maxName)
+	for _, ring := range names {
+		_, _ = fmt.Fprintf(sb, "%s{ring=\"%s\"} %s\n", maxName, escape(ring), seconds(stats[ring].Residence.Max))
+	}
+}
+
+func seconds(d time.Duration) string { return strconv.FormatFloat(d.Seconds(), 'g', -1, 64) }
+
+// escape escapes a label value of the Prometheus text format.
+func escape(v string) string { return escaper.Replace(v) }
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Handler returns an http.Handler which serves the statistics in the
+// Prometheus text exposition format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = r.WritePrometheus(w)
+	})
+}
+
+// Register adds src as name to the [Default] registry.
+func Register(name string, src Source) error { return Default.Register(name, src) }
+
+// Unregister removes name from the [Default] registry.
+func Unregister(name string) { Default.Unregister(name) }
+
+// Publish publishes the [Default] registry as the expvar variable
+// name.
+func Publish(name string) { Default.Publish(name) }
+
+// Handler serves the [Default] registry in the Prometheus text
+// exposition format.
+func Handler() http.Handler { return Default.Handler() }
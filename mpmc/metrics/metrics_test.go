@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hedzr/go-ringbuf/v2/mpmc"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	events := mpmc.New[int](8, mpmc.WithMetrics[int](), mpmc.WithResidence[int]())
+	logs := mpmc.NewOverlappedRingBuffer[string](4, mpmc.WithMetrics[string]())
+	if err := r.Register("events", events); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(`logs "x"`, logs); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("events", logs); !errors.Is(err, ErrDuplicateName) {
+		t.Fatalf("expect ErrDuplicateName but got %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		_ = events.Enqueue(i)
+	}
+	_, _ = events.Dequeue()
+	for i := 0; i < 5; i++ {
+		_ = logs.Enqueue("line")
+	}
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	body := rec.Body.String()
+	for _, line := range []string{
+		"# TYPE ringbuf_size gauge",
+		`ringbuf_size{ring="events"} 2`,
+		`ringbuf_capacity{ring="events"} 7`,
+		`ringbuf_enqueued_total{ring="events"} 3`,
+		`ringbuf_dequeued_total{ring="events"} 1`,
+		`ringbuf_overwrites_total{ring="logs \"x\""} 2`,
+		`ringbuf_rejections_total{ring="events",reason="empty"} 0`,
+		"# TYPE ringbuf_rejections_total counter",
+		"# TYPE ringbuf_residence_seconds summary",
+		`ringbuf_residence_seconds_count{ring="events"} 1`,
+		`ringbuf_residence_seconds_count{ring="logs \"x\""} 0`,
+		`ringbuf_residence_max_seconds{ring="logs \"x\""} 0`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Fatalf("expect %q in:\n%v", line, body)
+		}
+	}
+	if strings.Count(body, "# TYPE ringbuf_rejections_total") != 1 {
+		t.Fatalf("expect a family declared once:\n%v", body)
+	}
+
+	var vars map[string]mpmc.Stats
+	if err := json.Unmarshal([]byte(r.Var().String()), &vars); err != nil {
+		t.Fatal(err)
+	}
+	if s := vars["events"]; s.Enqueued != 3 || s.Size != 2 {
+		t.Fatalf("unexpected expvar value: %+v", vars)
+	}
+
+	r.Unregister("events")
+	if _, ok := r.Stats()["events"]; ok {
+		t.Fatal("expect events unregistered")
+	}
+}
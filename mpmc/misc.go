@@ -1,13 +1,13 @@
 package mpmc
 
 import (
-	"fmt"
-	"runtime"
-	"strings"
+	"errors"
+	"math/bits"
 	"sync/atomic"
 )
 
-// Dbg exposes some internal fields for debugging
+// Dbg exposes some internal fields for debugging, see also
+// [RingBuffer.Stats].
 type Dbg interface {
 	GetGetWaits() uint64
 	GetPutWaits() uint64
@@ -15,25 +15,46 @@ type Dbg interface {
 	ResetCounters()
 }
 
-func (rb *ringBuf[T]) GetGetWaits() uint64 {
-	return atomic.LoadUint64(&rb.getWaits)
+// Close marks the ring buffer closed. After that, Enqueue returns
+// [ErrQueueClosed], and Dequeue keeps returning the remaining
+// elements till the ring buffer is drained, then [ErrQueueClosed]
+// rather than [ErrQueueEmpty]. The blocked producers and consumers
+// will be woken up.
+//
+// Close is not a barrier: an Enqueue racing with it might still
+// succeed. Closing a closed ring buffer is a no-op.
+func (rb *ringBuf[T]) Close() {
+	if atomic.CompareAndSwapUint32(&rb.closed, 0, 1) {
+		rb.notFull.broadcast()
+		rb.notEmpty.broadcast()
+		rb.event(evClose)
+	}
 }
 
-func (rb *ringBuf[T]) GetPutWaits() uint64 {
-	return atomic.LoadUint64(&rb.putWaits)
+// IsClosed reports whether the ring buffer has been closed.
+func (rb *ringBuf[T]) IsClosed() bool {
+	return atomic.LoadUint32(&rb.closed) != 0
 }
 
-func (rb *ringBuf[T]) ResetCounters() {
-	atomic.StoreUint64(&rb.getWaits, 0)
-	atomic.StoreUint64(&rb.putWaits, 0)
-}
+// CloseAndDrain closes the ring buffer and returns the remaining
+// elements, it's useful for a graceful shutdown.
+func (rb *ringBuf[T]) CloseAndDrain() []T { return closeAndDrain[T](rb) }
 
-func (rb *ringBuf[T]) Close() {
-	// if rb.logger != nil {
-	// 	// err = rb.logger.Flush()
-	// 	rb.logger = nil
-	// }
-	// return
+func (rb *orbuf[T]) CloseAndDrain() []T { return closeAndDrain[T](rb) }
+
+func closeAndDrain[T any](q interface {
+	dequeuer[T]
+	Close()
+},
+) (items []T) {
+	q.Close()
+	for {
+		it, err := q.Dequeue()
+		if err != nil && !errors.Is(err, ErrRaced) {
+			return
+		}
+		items = append(items, it)
+	}
 }
 
 func (rb *ringBuf[T]) qty(head, tail uint32) (quantity uint32) {
@@ -49,10 +70,12 @@ func (rb *ringBuf[T]) Quantity() uint32 {
 	return rb.Size()
 }
 
+// Size returns the quantity of elements, including the in-flight
+// ones. It's always in the range [0, CapReal()], and consistent when
+// the head and tail are read as a pair which held at the same moment,
+// see [ringBuf.indices].
 func (rb *ringBuf[T]) Size() (quantity uint32) {
-	var tail, head uint32
-	head = atomic.LoadUint32(&rb.head)
-	tail = atomic.LoadUint32(&rb.tail)
+	head, tail := rb.indices()
 	return rb.qty(head, tail)
 }
 
@@ -64,89 +87,75 @@ func (rb *ringBuf[T]) CapReal() uint32 {
 	return rb.capModMask
 }
 
+// IsEmpty reports whether the ring buffer is empty, see also Size.
 func (rb *ringBuf[T]) IsEmpty() (b bool) {
-	var tail, head uint32
-	head = atomic.LoadUint32(&rb.head)
-	tail = atomic.LoadUint32(&rb.tail)
+	head, tail := rb.indices()
 	b = head == tail
 	return
 }
 
+// IsFull reports whether the ring buffer is full, see also Size.
 func (rb *ringBuf[T]) IsFull() (b bool) {
-	var tail, head uint32
-	head = atomic.LoadUint32(&rb.head)
-	tail = atomic.LoadUint32(&rb.tail)
+	head, tail := rb.indices()
 	b = ((tail + 1) & rb.capModMask) == head
 	return
 }
 
-// Reset will clear the whole queue, but it might be unsafe in SMP runtime environment.
-func (rb *ringBuf[T]) Reset() {
+// consistentReads is the number of attempts to read a consistent
+// pair of indices, before falling back to a merely bounded one.
+const consistentReads = 8
+
+// indices returns the head and tail which held at the same moment:
+// the tail is read again after the head, and the pair is taken only
+// if the tail didn't move in between (barring a wrap of the whole
+// ring buffer). Under heavy contention it gives up after a few
+// attempts, and takes the tail read last, which is never behind the
+// head read before it. Such a pair is not a snapshot, the quantity
+// told by it is merely within the capacity, see also
+// [seqRingBuf.Size64].
+//
+// Both are 0 while the ring buffer is being reset.
+func (rb *ringBuf[T]) indices() (head, tail uint32) {
+	for i := 0; i < consistentReads; i++ {
+		t := atomic.LoadUint32(&rb.tail)
+		head = atomic.LoadUint32(&rb.head)
+		if tail = atomic.LoadUint32(&rb.tail); tail == t {
+			break
+		}
+	}
+	if head == MaxUint32 || tail == MaxUint32 {
+		head, tail = 0, 0 // not ready
+	}
+	return
+}
+
+// reset clears the whole queue, the caller must quiesce it first.
+//
+// The referenced values are released, and the slots are pre-populated
+// again if an [Initializeable] has been specified.
+func (rb *ringBuf[T]) reset() {
 	// atomic.StoreUint64((*uint64)(unsafe.Pointer(&rb.head)), MaxUint64)
 	atomic.StoreUint32(&rb.head, MaxUint32)
 	atomic.StoreUint32(&rb.tail, MaxUint32)
-	for i := 0; i < int(rb.cap); i++ {
-		rb.data[i].readWrite = 0 // bit 0: readable, bit 1: writable
+	var zero T
+	for i := uint32(0); i < rb.cap; i++ {
+		it := rb.at(i)
+		it.readWrite = 0 // bit 0: readable, bit 1: writable
+		it.value = zero  // release the referenced objects
 	}
+	rb.preAlloc()
 	// atomic.StoreUint64((*uint64)(unsafe.Pointer(&rb.head)), 0)
 	atomic.StoreUint32(&rb.head, 0)
 	atomic.StoreUint32(&rb.tail, 0)
 }
 
-func (rb *ringBuf[T]) Debug(enabled bool) (lastState bool) {
-	// lastState = rb.debugMode
-	// rb.debugMode = enabled
-	_ = enabled
-	return
-}
-
-func (rb *ringBuf[T]) String() (ret string) {
-	var sb strings.Builder
-	_, _ = sb.WriteRune('[')
-
-	head := atomic.LoadUint32(&rb.head)
-	tail := atomic.LoadUint32(&rb.tail)
-	if head < tail {
-		for i := head; i < tail; i++ {
-			it := &rb.data[i]
-		retry:
-			if st := atomic.LoadUint64(&it.readWrite); st > 1 {
-				runtime.Gosched() // time to time
-				goto retry
-			}
-			str := fmt.Sprintf("%v,", it.value)
-			_, _ = sb.WriteString(str)
-		}
-	} else if head > tail {
-		for i := head; i < rb.cap; i++ {
-			it := &rb.data[i]
-		retry1:
-			if st := atomic.LoadUint64(&it.readWrite); st > 1 {
-				runtime.Gosched() // time to time
-				goto retry1
-			}
-			str := fmt.Sprintf("%v,", it.value)
-			_, _ = sb.WriteString(str)
-		}
-
-		for i := uint32(0); i < tail; i++ {
-			it := &rb.data[i]
-		retry2:
-			if st := atomic.LoadUint64(&it.readWrite); st > 1 {
-				runtime.Gosched() // time to time
-				goto retry2
-			}
-			str := fmt.Sprintf("%v,", it.value)
-			_, _ = sb.WriteString(str)
-		}
+// roundUpToPower2x64 is the 64-bit version of roundUpToPower2, it
+// returns 0 if v is 0 or greater than 1<<63.
+func roundUpToPower2x64(v uint64) uint64 {
+	if v == 0 || v > 1<<63 {
+		return 0
 	}
-
-	// _, _ = sb.WriteRune(']')
-	// _, _ = sb.WriteRune('/')
-	str := fmt.Sprintf("]/%v", rb.Size())
-	_, _ = sb.WriteString(str)
-	ret = sb.String()
-	return
+	return 1 << bits.Len64(v-1)
 }
 
 // roundUpToPower2 takes a uint32 positive integer and
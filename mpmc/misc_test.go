@@ -0,0 +1,102 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRingBuf_Close(t *testing.T) {
+	rb := New[int](8)
+	for i := 0; i < 3; i++ {
+		checkerr(t, rb.Enqueue(i))
+	}
+
+	rb.Close()
+	rb.Close() // no-op
+	if !rb.IsClosed() {
+		t.Fatal("expect a closed ring buffer")
+	}
+
+	if err := rb.Enqueue(3); !errors.Is(err, ErrQueueClosed) {
+		t.Fatalf("expect ErrQueueClosed but got %v", err)
+	}
+	if _, err := rb.EnqueueBatch([]int{3, 4}); !errors.Is(err, ErrQueueClosed) {
+		t.Fatalf("expect ErrQueueClosed but got %v", err)
+	}
+
+	// the remaining elements are still available
+	for i := 0; i < 3; i++ {
+		it, err := rb.Dequeue()
+		if err != nil || it != i {
+			t.Fatalf("expect %v but got %v, err: %v", i, it, err)
+		}
+	}
+	if _, err := rb.Dequeue(); !errors.Is(err, ErrQueueClosed) {
+		t.Fatalf("expect ErrQueueClosed but got %v", err)
+	}
+	if _, err := rb.DequeueBatch(make([]int, 2)); !errors.Is(err, ErrQueueClosed) {
+		t.Fatalf("expect ErrQueueClosed but got %v", err)
+	}
+}
+
+func TestRingBuf_CloseWakesWaiters(t *testing.T) {
+	rb := New[int](2)
+	orb := NewOverlappedRingBuffer[int](2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	errs := make(chan error, 3)
+	go func() {
+		_, err := rb.DequeueCtx(ctx)
+		errs <- err
+	}()
+	go func() {
+		_, err := orb.DequeueCtx(ctx)
+		errs <- err
+	}()
+
+	full := New[int](2)
+	checkerr(t, full.Enqueue(1))
+	go func() {
+		errs <- full.EnqueueCtx(ctx, 2)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	rb.Close()
+	orb.Close()
+	full.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := <-errs; !errors.Is(err, ErrQueueClosed) {
+			t.Fatalf("expect ErrQueueClosed but got %v", err)
+		}
+	}
+}
+
+func TestRingBuf_CloseAndDrain(t *testing.T) {
+	rb := New[int](8)
+	for i := 0; i < 5; i++ {
+		checkerr(t, rb.Enqueue(i))
+	}
+	if items := rb.CloseAndDrain(); fmt.Sprint(items) != "[0 1 2 3 4]" {
+		t.Fatalf("unexpected remaining elements: %v", items)
+	}
+	if items := rb.CloseAndDrain(); len(items) != 0 {
+		t.Fatalf("expect nothing but got %v", items)
+	}
+
+	orb := NewOverlappedRingBuffer[int](4)
+	for i := 0; i < 5; i++ {
+		checkerr(t, orb.Enqueue(i))
+	}
+	if items := orb.CloseAndDrain(); fmt.Sprint(items) != "[2 3 4]" {
+		t.Fatalf("unexpected remaining elements: %v", items)
+	}
+	if err := orb.Enqueue(5); !errors.Is(err, ErrQueueClosed) {
+		t.Fatalf("expect ErrQueueClosed but got %v", err)
+	}
+}
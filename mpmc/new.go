@@ -4,17 +4,28 @@ package mpmc
 //
 // It returns [ErrQueueFull] when you're trying to put a new
 // element into a full ring buffer.
+//
+// The capacity is rounded up to a power of 2, and one slot is
+// sacrificed to tell full from empty, so CapReal() is Cap()-1.
+// Use [WithExactCapacity] to hold exactly capacity elements, and
+// [TryNew] to validate the capacity.
+//
+// With [WithExactCapacity], the returned value is nil if capacity is
+// zero, or too large to be allocated. Call [TryNew] rather than
+// checking it for nil, to learn the reason.
 func New[T any](capacity uint32, opts ...Opt[T]) (ringBuffer RingBuffer[T]) {
 	return newRingBuffer(func(capacity uint32, opts ...Opt[T]) (ringBuffer RingBuffer[T]) {
-		size := roundUpToPower2(capacity)
-		rb := &ringBuf[T]{
-			data:       make([]rbItem[T], size),
-			cap:        size,
-			capModMask: size - 1, // = 2^n - 1
-		}
+		rb := &ringBuf[T]{}
 		for _, opt := range opts {
 			opt(rb)
 		}
+		if rb.exactCapacity {
+			if srb, err := newSeqRingBuf(uint64(capacity), opts...); err == nil {
+				ringBuffer = srb
+			}
+			return
+		}
+		rb.alloc(roundUpToPower2(capacity))
 		ringBuffer = rb
 		return
 	}, capacity, opts...)
@@ -33,17 +44,11 @@ func New[T any](capacity uint32, opts ...Opt[T]) (ringBuffer RingBuffer[T]) {
 // impossible, so ignore it is safe.
 func NewOverlappedRingBuffer[T any](capacity uint32, opts ...Opt[T]) (ringBuffer RichOverlappedRingBuffer[T]) {
 	return newOverlappedRingBuffer(func(capacity uint32, opts ...Opt[T]) (ringBuffer RichOverlappedRingBuffer[T]) {
-		size := roundUpToPower2(capacity)
-		rb := &orbuf[T]{
-			ringBuf[T]{
-				data:       make([]rbItem[T], size),
-				cap:        size,
-				capModMask: size - 1, // = 2^n - 1
-			},
-		}
+		rb := &orbuf[T]{}
 		for _, opt := range opts {
 			opt(&rb.ringBuf)
 		}
+		rb.alloc(roundUpToPower2(capacity))
 		ringBuffer = rb
 		return
 	}, capacity, opts...)
@@ -70,23 +75,69 @@ func newRingBuffer[T any](creator Creator[T], capacity uint32, opts ...Opt[T]) (
 // Opt interface the functional options
 type Opt[T any] func(rb *ringBuf[T])
 
-// WithItemInitializer provides your custom initializer for each data item.
+// WithItemInitializer provides your custom initializer for each data item,
+// every slot is pre-populated by [Initializeable.PreAlloc].
 func WithItemInitializer[T any](initializeable Initializeable[T]) Opt[T] {
 	return func(buf *ringBuf[T]) {
 		buf.initializer = initializeable
 	}
 }
 
-// WithDebugMode enables the internal debug mode for more logging output, and collect the metrics for debugging
-func WithDebugMode[T any](_ bool) Opt[T] {
-	return func(_ *ringBuf[T]) {
-		// buf.debugMode = debug
+// WithWaitStrategy specifies how the producers and consumers wait
+// while they cannot make progress, see [WaitStrategy].
+//
+// The default is [ParkingWait].
+func WithWaitStrategy[T any](ws WaitStrategy) Opt[T] {
+	return func(buf *ringBuf[T]) {
+		buf.waitStrategy = ws
+	}
+}
+
+// WithExactCapacity makes a ring buffer of capacity N hold exactly N
+// elements, with Cap() == CapReal() == N.
+//
+// The capacity is not rounded up to a power of 2, and no slot is
+// sacrificed: the slots are claimed by the monotonically increasing
+// 64-bit positions like [NewSequenced], and located by modulo rather
+// than masking, which costs a division per operation.
+//
+// It's honoured by [New] and [NewSequenced], the overlapped ring
+// buffer and the fixed topologies ignore it.
+func WithExactCapacity[T any]() Opt[T] {
+	return func(buf *ringBuf[T]) {
+		buf.exactCapacity = true
+	}
+}
+
+// WithCompactLayout packs the slots without padding them to the cache
+// line, it saves the memory for the small elements, such as 16 bytes
+// rather than 64 bytes per slot for uint32, at the cost of the false
+// sharing between the neighbouring slots.
+func WithCompactLayout[T any]() Opt[T] {
+	return func(buf *ringBuf[T]) {
+		buf.compact = true
 	}
 }
 
-// // WithLogger setup a logger
-// func WithLogger[T any](logger log.Logger) Opt[T] {
-// 	return func(buf *ringBuf[T]) {
-// 		// buf.logger = logger
-// 	}
-// }
+// WithZeroOnDequeue specifies whether a slot is cleared once its
+// element is dequeued or released, so that GC can reclaim the objects
+// referenced by it, such as a big []byte payload.
+//
+// By default, it's enabled if T contains any pointer, slice, string,
+// map, etc. It has no effect with [WithItemInitializer], whose slot
+// buffers are kept for reuse.
+func WithZeroOnDequeue[T any](enabled bool) Opt[T] {
+	return func(buf *ringBuf[T]) {
+		buf.zeroOnDequeue, buf.zeroSet = enabled, true
+	}
+}
+
+// WithDebugMode enables the internal debug mode, which collects the
+// metrics like [WithMetrics], see also [Dbg].
+func WithDebugMode[T any](debug bool) Opt[T] {
+	return func(buf *ringBuf[T]) {
+		if debug {
+			buf.metrics.Store(newMetrics())
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package mpmc
+
+import (
+	"sync/atomic"
+)
+
+// Peek returns the head element without consuming it. It returns
+// [ErrQueueEmpty] (or [ErrQueueClosed] once closed) if the ring
+// buffer is empty, or the head element is still being written, and
+// [ErrQueueNotReady] while it's being reset.
+//
+// The element is copied out while its slot is held in the reading
+// state, so a slot being written is never observed, and a consumer
+// taking it meanwhile waits for the copy. The element was the head
+// at some moment during the call, but it might have been taken once
+// Peek returns.
+func (rb *ringBuf[T]) Peek() (item T, err error) { return peekAt(rb.peek, 0) }
+
+// PeekAt returns the element at the logical position i without
+// consuming it, the head element is at position 0. It returns
+// [ErrQueueEmpty] if there is no element at i, see also Peek.
+func (rb *ringBuf[T]) PeekAt(i uint32) (item T, err error) { return peekAt(rb.peek, i) }
+
+// PeekN copies the leading elements into dst without consuming them,
+// and returns how many were copied.
+//
+// The copied elements are a contiguous run from the head, but PeekN
+// stops early if a consumer moves the head meanwhile.
+func (rb *ringBuf[T]) PeekN(dst []T) (n int) { n, _ = rb.peek(0, dst); return }
+
+func peekAt[T any](peek func(at uint32, dst []T) (int, error), i uint32) (item T, err error) {
+	var dst [1]T
+	if _, err = peek(i, dst[:]); err == nil {
+		item = dst[0]
+	}
+	return
+}
+
+// peek copies up to len(dst) elements from the logical position at.
+// It retries if the head moves meanwhile, but never waits for a
+// producer: an element being written is not there yet.
+func (rb *ringBuf[T]) peek(at uint32, dst []T) (n int, err error) {
+	if len(dst) == 0 {
+		return
+	}
+	if err = rb.enter(observing); err != nil {
+		return
+	}
+	defer rb.leave(observing)
+
+	for spins := 0; ; spins++ {
+		head := atomic.LoadUint32(&rb.head)
+		tail := atomic.LoadUint32(&rb.tail)
+		qty := rb.qty(head, tail)
+		if at >= qty {
+			err = ErrQueueEmpty
+			if qty == 0 && rb.IsClosed() {
+				err = ErrQueueClosed
+			}
+			return
+		}
+
+		for ; n < len(dst) && at+uint32(n) < qty; n++ {
+			holder := rb.at((head + at + uint32(n)) & rb.capModMask)
+			if !atomic.CompareAndSwapUint64(&holder.readWrite, 1, 3) { //nolint:gomnd
+				break // being written, or taken by a consumer
+			}
+			if atomic.LoadUint32(&rb.head) != head {
+				// the run is not from the head anymore
+				atomic.CompareAndSwapUint64(&holder.readWrite, 3, 1) //nolint:gomnd
+				break
+			}
+			dst[n] = rb.view(holder)
+			atomic.CompareAndSwapUint64(&holder.readWrite, 3, 1) //nolint:gomnd
+		}
+		if n > 0 {
+			return
+		}
+		holder := rb.at((head + at) & rb.capModMask)
+		if st := atomic.LoadUint64(&holder.readWrite); st != 1 && st != 3 && atomic.LoadUint32(&rb.head) == head {
+			err = ErrQueueEmpty // claimed by a producer, but not written yet
+			return
+		}
+		rb.idle(spins + 1)
+	}
+}
+
+// view copies the value out of a slot without taking it.
+func (rb *ringBuf[T]) view(holder *rbItem[T]) T {
+	if rb.initializer != nil {
+		return rb.initializer.CloneOut(&holder.value)
+	}
+	return holder.value
+}
+
+// Peek returns the head element without consuming it, see also
+// [ringBuf.Peek].
+//
+// In SPSC mode, the peeking methods must be called by the consumer
+// goroutine, since there are no slot states to hold the slots.
+func (rb *topoRingBuf[T]) Peek() (item T, err error) { return peekAt(rb.peek, 0) }
+
+func (rb *topoRingBuf[T]) PeekAt(i uint32) (item T, err error) { return peekAt(rb.peek, i) } //nolint:revive
+
+func (rb *topoRingBuf[T]) PeekN(dst []T) (n int) { n, _ = rb.peek(0, dst); return } //nolint:revive
+
+func (rb *topoRingBuf[T]) peek(at uint32, dst []T) (n int, err error) {
+	if rb.multiProducers || rb.multiConsumers {
+		return rb.ringBuf.peek(at, dst)
+	}
+	if len(dst) == 0 {
+		return
+	}
+	if err = rb.enter(observing); err != nil {
+		return
+	}
+	defer rb.leave(observing)
+
+	// the caller is the consumer, so the head cannot be moved, and
+	// everything before the tail has been published.
+	head := atomic.LoadUint32(&rb.head)
+	qty := rb.qty(head, atomic.LoadUint32(&rb.tail))
+	if at >= qty {
+		err = rb.errEmpty(head)
+		return
+	}
+	for ; n < len(dst) && at+uint32(n) < qty; n++ {
+		dst[n] = rb.view(rb.at((head + at + uint32(n)) & rb.capModMask))
+	}
+	return
+}
+
+// Peek returns the head element without consuming it, see also
+// [ringBuf.Peek].
+//
+// A slot is held by setting [peekedSeq] in its sequence number, a
+// consumer taking it meanwhile waits for the copy.
+func (rb *seqRingBuf[T]) Peek() (item T, err error) { return peekAt(rb.peek, 0) }
+
+func (rb *seqRingBuf[T]) PeekAt(i uint32) (item T, err error) { return peekAt(rb.peek, i) } //nolint:revive
+
+func (rb *seqRingBuf[T]) PeekN(dst []T) (n int) { n, _ = rb.peek(0, dst); return } //nolint:revive
+
+func (rb *seqRingBuf[T]) peek(at uint32, dst []T) (n int, err error) {
+	if len(dst) == 0 {
+		return
+	}
+	if err = rb.enter(observing); err != nil {
+		return
+	}
+	defer rb.leave(observing)
+
+	for spins := 0; ; spins++ {
+		deq := atomic.LoadUint64(&rb.deqPos)
+		enq := atomic.LoadUint64(&rb.enqPos) &^ sealedPos
+		if enq <= deq+uint64(at) {
+			err = ErrQueueEmpty
+			if enq <= deq && rb.IsClosed() {
+				err = ErrQueueClosed
+			}
+			return
+		}
+
+		for ; n < len(dst) && deq+uint64(at)+uint64(n) < enq; n++ {
+			pos := deq + uint64(at) + uint64(n)
+			holder := rb.slot(pos)
+			if !atomic.CompareAndSwapUint64(&holder.readWrite, pos+1, pos+1|peekedSeq) {
+				break // being written, or held by another peeker
+			}
+			if atomic.LoadUint64(&rb.deqPos) != deq {
+				// the run is not from the head anymore
+				atomic.CompareAndSwapUint64(&holder.readWrite, pos+1|peekedSeq, pos+1)
+				break
+			}
+			dst[n] = rb.view(holder)
+			atomic.CompareAndSwapUint64(&holder.readWrite, pos+1|peekedSeq, pos+1)
+		}
+		if n > 0 {
+			return
+		}
+		pos := deq + uint64(at)
+		if atomic.LoadUint64(&rb.slot(pos).readWrite) == pos && atomic.LoadUint64(&rb.deqPos) == deq {
+			err = ErrQueueEmpty // claimed by a producer, but not written yet
+			return
+		}
+		rb.idle(spins + 1)
+	}
+}
+
+func (g *growRingBuf[T]) Peek() (item T, err error) { return g.PeekAt(0) } //nolint:revive
+
+func (g *growRingBuf[T]) PeekAt(i uint32) (item T, err error) { //nolint:revive
+	s := g.enter()
+	defer g.leave(s)
+	return s.rb.PeekAt(i)
+}
+
+func (g *growRingBuf[T]) PeekN(dst []T) int { //nolint:revive
+	return within(g, func(rb *seqRingBuf[T]) int { return rb.PeekN(dst) })
+}
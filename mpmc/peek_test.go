@@ -0,0 +1,150 @@
+package mpmc
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPeek(t *testing.T) {
+	for _, c := range allCreators(1) {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.create(8)
+			defer rb.Close()
+
+			if _, err := rb.Peek(); !errors.Is(err, ErrQueueEmpty) {
+				t.Fatalf("expect ErrQueueEmpty but got %v", err)
+			}
+			for i := 1; i <= 3; i++ {
+				checkerr(t, rb.Enqueue(i))
+			}
+			if it, err := rb.Peek(); err != nil || it != 1 || rb.Size() != 3 {
+				t.Fatalf("expect 1 peeked from 3 elements, but got %v (%v), err: %v", it, rb.Size(), err)
+			}
+			if it, err := rb.PeekAt(2); err != nil || it != 3 {
+				t.Fatalf("expect 3 at position 2, but got %v, err: %v", it, err)
+			}
+			if _, err := rb.PeekAt(3); !errors.Is(err, ErrQueueEmpty) {
+				t.Fatalf("expect ErrQueueEmpty at position 3, but got %v", err)
+			}
+			dst := make([]int, 5)
+			if n := rb.PeekN(dst); n != 3 || !reflect.DeepEqual(dst[:n], []int{1, 2, 3}) {
+				t.Fatalf("expect [1 2 3] peeked, but got %v", dst[:n])
+			}
+			if n := rb.PeekN(dst[:2]); n != 2 || !reflect.DeepEqual(dst[:n], []int{1, 2}) {
+				t.Fatalf("expect [1 2] peeked, but got %v", dst[:n])
+			}
+
+			if it, err := rb.Dequeue(); err != nil || it != 1 {
+				t.Fatalf("expect 1 but got %v, err: %v", it, err)
+			}
+			if it, err := rb.Peek(); err != nil || it != 2 {
+				t.Fatalf("expect 2 peeked but got %v, err: %v", it, err)
+			}
+			_ = rb.CloseAndDrain()
+			if _, err := rb.Peek(); !errors.Is(err, ErrQueueClosed) {
+				t.Fatalf("expect ErrQueueClosed but got %v", err)
+			}
+		})
+	}
+}
+
+// TestPeek_Reserved peeks at a slot reserved but not committed yet,
+// Peek must not wait for the producer.
+func TestPeek_Reserved(t *testing.T) {
+	for _, c := range allCreators(1) {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.create(8)
+			defer rb.Close()
+
+			checkerr(t, rb.Enqueue(1))
+			ptr, ticket, err := rb.Reserve()
+			checkerr(t, err)
+			if _, err = rb.PeekAt(1); !errors.Is(err, ErrQueueEmpty) {
+				t.Fatalf("expect ErrQueueEmpty at the reserved slot, but got %v", err)
+			}
+			dst := make([]int, 2)
+			if n := rb.PeekN(dst); n != 1 || dst[0] != 1 {
+				t.Fatalf("expect [1] peeked, but got %v", dst[:n])
+			}
+			if _, err = rb.Dequeue(); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if _, err = rb.Peek(); !errors.Is(err, ErrQueueEmpty) {
+				t.Fatalf("expect ErrQueueEmpty at the reserved head, but got %v", err)
+			}
+			*ptr = 2
+			rb.Commit(ticket)
+			if it, err := rb.Peek(); err != nil || it != 2 {
+				t.Fatalf("expect 2 peeked once committed, but got %v, err: %v", it, err)
+			}
+		})
+	}
+}
+
+// pair detects a torn read, b is always the negative a.
+type pair struct{ a, b int }
+
+// TestPeek_Concurrent peeks while the consumers are taking the
+// elements away, a peeked element must be never torn, and the head
+// never goes backward.
+func TestPeek_Concurrent(t *testing.T) {
+	const consumers, count = 2, 5000
+	for _, c := range []struct {
+		name   string
+		create func(capacity uint32, opts ...Opt[pair]) RingBuffer[pair]
+	}{
+		{"MPMC", New[pair]},
+		{"SPMC", NewSPMC[pair]},
+		{"Sequenced", NewSequenced[pair]},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.create(16)
+			defer rb.Close()
+
+			var taken int64
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 1; i <= count; {
+					if rb.Enqueue(pair{i, -i}) == nil {
+						i++
+					} else {
+						runtime.Gosched()
+					}
+				}
+			}()
+			for q := 0; q < consumers; q++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for atomic.LoadInt64(&taken) < count {
+						if _, err := rb.Dequeue(); err == nil {
+							atomic.AddInt64(&taken, 1)
+						} else {
+							runtime.Gosched()
+						}
+					}
+				}()
+			}
+
+			last := 0
+			for atomic.LoadInt64(&taken) < count {
+				it, err := rb.Peek()
+				if err != nil {
+					runtime.Gosched()
+					continue
+				}
+				if it.a != -it.b || it.a < last {
+					t.Fatalf("expect a head after %v, but got %v", last, it)
+				}
+				last = it.a
+			}
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,356 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Pool is a fixed resource pool backed by a ring buffer. Unlike
+// sync.Pool, it bounds the number of live resources: Acquire blocks
+// while all of them have been checked out.
+type Pool[T any] interface {
+	// Acquire checks out an idle resource, or creates one if the
+	// pool has not reached its size. It blocks till a resource is
+	// released or ctx is done, and returns [ErrQueueClosed] once
+	// the pool has been closed.
+	Acquire(ctx context.Context) (res T, err error)
+	// Release gives a resource returned by Acquire back to the pool.
+	// The invalid resources are destroyed and replaced.
+	//
+	// With [WithPoolLeakDetection], a resource which is not checked
+	// out, such as one released twice or from another pool, is
+	// ignored. Without it, the caller must release a resource once.
+	Release(res T)
+	// Evict destroys the leading resources which have been idle for
+	// longer than the max-idle duration, and returns how many were
+	// evicted. It's also done lazily by Acquire.
+	Evict() (n int)
+	// Leaks returns the resources checked out for longer than age
+	// and never released. It requires [WithPoolLeakDetection].
+	Leaks(age time.Duration) (leaked []T)
+
+	Idle() int // Idle returns the number of idle resources
+	Live() int // Live returns the number of idle and checked-out resources
+	Size() int // Size returns the maximal number of live resources
+
+	// Close destroys the idle resources, and the checked-out ones
+	// once they are released.
+	Close()
+}
+
+// PoolOpt the functional options for [NewPool]
+type PoolOpt[T any] func(p *pool[T])
+
+// WithPoolValidator checks a resource on Release, the resource is
+// destroyed and replaced if validate returns false.
+func WithPoolValidator[T any](validate func(res T) bool) PoolOpt[T] {
+	return func(p *pool[T]) {
+		p.validate = validate
+	}
+}
+
+// WithPoolResetter resets a valid resource on Release, before it
+// goes back to the pool.
+func WithPoolResetter[T any](reset func(res T)) PoolOpt[T] {
+	return func(p *pool[T]) {
+		p.reset = reset
+	}
+}
+
+// WithPoolDestroyer releases the underlying things of a resource,
+// such as a connection, when it's evicted, invalid or closed.
+func WithPoolDestroyer[T any](destroy func(res T)) PoolOpt[T] {
+	return func(p *pool[T]) {
+		p.destroy = destroy
+	}
+}
+
+// WithPoolMaxIdle evicts the resources which have been idle for
+// longer than d, see [Pool.Evict].
+func WithPoolMaxIdle[T any](d time.Duration) PoolOpt[T] {
+	return func(p *pool[T]) {
+		p.maxIdle = d
+	}
+}
+
+// WithPoolLeakDetection tracks the checked-out resources, so that
+// the never released ones can be reported by [Pool.Leaks]. The
+// resources are told apart by ==, so T must be comparable here only.
+func WithPoolLeakDetection[T comparable]() PoolOpt[T] {
+	return func(p *pool[T]) {
+		p.out = &leakTracker[T]{out: make(map[T]time.Time)}
+	}
+}
+
+// NewPool returns a resource pool of size resources, which are
+// created by factory in advance.
+//
+// If factory fails, the created resources are destroyed and the
+// error is returned. A zero size is [ErrInvalidCapacity].
+func NewPool[T any](size uint32, factory func() (T, error), opts ...PoolOpt[T]) (p Pool[T], err error) {
+	if size == 0 || size > MaxUint32>>1 {
+		err = ErrInvalidCapacity
+		return
+	}
+
+	rb := NewSequenced[pooled[T]](size)
+	if rb == nil {
+		err = ErrQueueNotReady
+		return
+	}
+
+	pl := &pool[T]{rb: rb, size: int32(size), factory: factory} //nolint:gosec
+	for _, opt := range opts {
+		opt(pl)
+	}
+
+	for i := uint32(0); i < size; i++ {
+		var res T
+		if res, err = factory(); err != nil {
+			pl.Close()
+			return
+		}
+		atomic.AddInt32(&pl.live, 1)
+		_ = rb.Enqueue(pl.idle(res))
+	}
+	p = pl
+	return
+}
+
+// pooled is an idle resource, since is in unix nanoseconds.
+type pooled[T any] struct {
+	res   T
+	since int64
+}
+
+type pool[T any] struct {
+	rb   RingBuffer[pooled[T]]
+	size int32
+	live int32
+
+	factory  func() (T, error)
+	validate func(res T) bool
+	reset    func(res T)
+	destroy  func(res T)
+	maxIdle  time.Duration
+
+	out tracker[T] // the checked-out resources, nil if leak detection is disabled
+}
+
+// tracker tracks the checked-out resources of a pool, see
+// [WithPoolLeakDetection].
+type tracker[T any] interface {
+	checkout(res T)
+	checkin(res T) (ok bool)
+	leaks(age time.Duration) (leaked []T)
+}
+
+type leakTracker[T comparable] struct {
+	mu  sync.Mutex
+	out map[T]time.Time
+}
+
+func (p *pool[T]) Acquire(ctx context.Context) (res T, err error) { //nolint:revive
+	var it pooled[T]
+	for {
+		if it, err = p.rb.Dequeue(); err != nil {
+			if errors.Is(err, ErrQueueClosed) {
+				return
+			}
+			if p.grow() {
+				if res, err = p.factory(); err != nil {
+					p.shrink()
+					return
+				}
+				p.checkout(res)
+				return
+			}
+			if it, err = p.rb.DequeueCtx(ctx); err != nil {
+				return
+			}
+		}
+
+		if p.expired(it, time.Now().UnixNano()) {
+			p.discard(it.res)
+			continue
+		}
+		res = it.res
+		p.checkout(res)
+		return
+	}
+}
+
+func (p *pool[T]) Release(res T) { //nolint:revive
+	if !p.checkin(res) {
+		return
+	}
+	if p.rb.IsClosed() {
+		p.discard(res)
+		return
+	}
+	if p.validate != nil && !p.validate(res) {
+		p.discard(res)
+		p.refill()
+		return
+	}
+	if p.reset != nil {
+		p.reset(res)
+	}
+	if err := p.enqueue(res); err != nil {
+		p.discard(res) // closed, or not from this pool
+	}
+}
+
+func (p *pool[T]) Evict() (n int) { //nolint:revive
+	if p.maxIdle <= 0 {
+		return
+	}
+	now := time.Now().UnixNano()
+	expired := func(it pooled[T]) bool { return p.expired(it, now) }
+	for {
+		// the idle resources are in the release order, so it stops at
+		// the first one which is still fresh.
+		it, ok, _ := p.rb.DequeueIf(expired)
+		if !ok {
+			return
+		}
+		p.discard(it.res)
+		n++
+	}
+}
+
+func (p *pool[T]) Leaks(age time.Duration) (leaked []T) { //nolint:revive
+	if p.out != nil {
+		leaked = p.out.leaks(age)
+	}
+	return
+}
+
+func (p *pool[T]) Idle() int { return int(p.rb.Size()) }
+
+func (p *pool[T]) Live() int { return int(atomic.LoadInt32(&p.live)) }
+
+func (p *pool[T]) Size() int { return int(p.size) }
+
+func (p *pool[T]) Close() { //nolint:revive
+	for _, it := range p.rb.CloseAndDrain() {
+		p.discard(it.res)
+	}
+}
+
+// idle wraps res to be put back into the ring buffer.
+func (p *pool[T]) idle(res T) (it pooled[T]) {
+	it.res = res
+	if p.maxIdle > 0 {
+		it.since = time.Now().UnixNano()
+	}
+	return
+}
+
+// enqueue puts res back into the ring buffer. The ring buffer can
+// hold all of the live resources, but it looks full for a moment
+// while a consumer has claimed the slot of the last lap and not freed
+// it yet, see [seqRingBuf.Enqueue], so it's retried till the ring
+// buffer is really full, which means res is not from this pool.
+func (p *pool[T]) enqueue(res T) (err error) {
+	for {
+		err = p.rb.Enqueue(p.idle(res))
+		if !errors.Is(err, ErrQueueFull) || p.rb.Size() >= p.rb.Cap() {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+func (p *pool[T]) expired(it pooled[T], now int64) bool {
+	return p.maxIdle > 0 && now-it.since > int64(p.maxIdle)
+}
+
+// grow reserves a live resource if the pool has not reached its size.
+func (p *pool[T]) grow() bool {
+	for {
+		live := atomic.LoadInt32(&p.live)
+		if live >= p.size {
+			return false
+		}
+		if atomic.CompareAndSwapInt32(&p.live, live, live+1) {
+			return true
+		}
+	}
+}
+
+// shrink gives back a live resource, the count never drops below
+// zero.
+func (p *pool[T]) shrink() {
+	for {
+		live := atomic.LoadInt32(&p.live)
+		if live <= 0 || atomic.CompareAndSwapInt32(&p.live, live, live-1) {
+			return
+		}
+	}
+}
+
+// refill creates a resource to replace a discarded one, so that the
+// blocked Acquire can be woken up.
+func (p *pool[T]) refill() {
+	if !p.grow() {
+		return
+	}
+	res, err := p.factory()
+	if err != nil {
+		p.shrink()
+		return
+	}
+	if err = p.enqueue(res); err != nil {
+		p.discard(res)
+	}
+}
+
+func (p *pool[T]) discard(res T) {
+	p.shrink()
+	if p.destroy != nil {
+		p.destroy(res)
+	}
+}
+
+func (p *pool[T]) checkout(res T) {
+	if p.out != nil {
+		p.out.checkout(res)
+	}
+}
+
+// checkin reports whether res is checked out, it's always true if
+// the leak detection is disabled.
+func (p *pool[T]) checkin(res T) (ok bool) {
+	return p.out == nil || p.out.checkin(res)
+}
+
+func (t *leakTracker[T]) checkout(res T) {
+	t.mu.Lock()
+	t.out[res] = time.Now()
+	t.mu.Unlock()
+}
+
+func (t *leakTracker[T]) checkin(res T) (ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok = t.out[res]; ok {
+		delete(t.out, res)
+	}
+	return
+}
+
+func (t *leakTracker[T]) leaks(age time.Duration) (leaked []T) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for res, since := range t.out {
+		if time.Since(since) >= age {
+			leaked = append(leaked, res)
+		}
+	}
+	return
+}
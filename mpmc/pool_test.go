@@ -0,0 +1,245 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type conn struct {
+	id     int
+	broken bool
+	used   int
+}
+
+func newConnFactory(created *int32) func() (*conn, error) {
+	return func() (*conn, error) {
+		return &conn{id: int(atomic.AddInt32(created, 1))}, nil
+	}
+}
+
+func TestPool_AcquireRelease(t *testing.T) {
+	var created, destroyed int32
+	p, err := NewPool(4, newConnFactory(&created),
+		WithPoolValidator(func(c *conn) bool { return !c.broken }),
+		WithPoolResetter(func(c *conn) { c.used = 0 }),
+		WithPoolDestroyer(func(*conn) { atomic.AddInt32(&destroyed, 1) }),
+	)
+	checkerr(t, err)
+	if created != 4 || p.Idle() != 4 || p.Live() != 4 || p.Size() != 4 {
+		t.Fatalf("expect 4 pre-filled resources, but created %v, idle %v, live %v", created, p.Idle(), p.Live())
+	}
+
+	var held []*conn
+	for i := 0; i < 4; i++ {
+		c, err := p.Acquire(context.Background())
+		checkerr(t, err)
+		c.used++
+		held = append(held, c)
+	}
+
+	// exhausted
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err = p.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expect DeadlineExceeded but got %v", err)
+	}
+
+	// a blocked Acquire is woken up by Release
+	done := make(chan *conn)
+	go func() {
+		c, err := p.Acquire(context.Background())
+		if err != nil {
+			t.Errorf("acquire failed: %v", err)
+		}
+		done <- c
+	}()
+	time.Sleep(10 * time.Millisecond)
+	p.Release(held[0])
+	if c := <-done; c != held[0] || c.used != 0 {
+		t.Fatalf("expect the released and reset resource, but got %+v", c)
+	}
+
+	// the broken one is destroyed and replaced
+	held[1].broken = true
+	p.Release(held[1])
+	if destroyed != 1 || created != 5 || p.Live() != 4 || p.Idle() != 1 {
+		t.Fatalf("expect a replaced resource, but destroyed %v, created %v, live %v", destroyed, created, p.Live())
+	}
+
+	p.Close()
+	if destroyed != 2 {
+		t.Fatalf("expect the idle resources destroyed, but got %v", destroyed)
+	}
+	if _, err = p.Acquire(context.Background()); !errors.Is(err, ErrQueueClosed) {
+		t.Fatalf("expect ErrQueueClosed but got %v", err)
+	}
+	p.Release(held[2])
+	if destroyed != 3 {
+		t.Fatalf("expect the released resource destroyed after closed, but got %v", destroyed)
+	}
+}
+
+func TestPool_Evict(t *testing.T) {
+	var created int32
+	p, err := NewPool(4, newConnFactory(&created), WithPoolMaxIdle[*conn](10*time.Millisecond))
+	checkerr(t, err)
+	defer p.Close()
+
+	time.Sleep(20 * time.Millisecond)
+	if n := p.Evict(); n != 4 || p.Live() != 0 {
+		t.Fatalf("expect 4 resources evicted, but got %v, live %v", n, p.Live())
+	}
+
+	c, err := p.Acquire(context.Background())
+	checkerr(t, err)
+	if c.id != 5 || p.Live() != 1 {
+		t.Fatalf("expect a new resource, but got %+v, live %v", c, p.Live())
+	}
+	p.Release(c)
+	if n := p.Evict(); n != 0 || p.Idle() != 1 {
+		t.Fatalf("expect nothing evicted, but got %v", n)
+	}
+
+	// evicted lazily
+	time.Sleep(20 * time.Millisecond)
+	if c, err = p.Acquire(context.Background()); err != nil || c.id != 6 {
+		t.Fatalf("expect a new resource, but got %+v, err: %v", c, err)
+	}
+}
+
+func TestPool_EvictOrder(t *testing.T) {
+	var created int32
+	p, err := NewPool(2, newConnFactory(&created), WithPoolMaxIdle[*conn](20*time.Millisecond))
+	checkerr(t, err)
+	defer p.Close()
+
+	c1, _ := p.Acquire(context.Background())
+	c2, _ := p.Acquire(context.Background())
+	p.Release(c1)
+	time.Sleep(30 * time.Millisecond)
+	p.Release(c2)
+	if n := p.Evict(); n != 1 || p.Idle() != 1 {
+		t.Fatalf("expect the stale one evicted, but got %v, idle %v", n, p.Idle())
+	}
+	if c, _ := p.Acquire(context.Background()); c != c2 {
+		t.Fatalf("expect %+v kept, but got %+v", c2, c)
+	}
+}
+
+func TestPool_ReleaseTwice(t *testing.T) {
+	var created int32
+	p, err := NewPool(2, newConnFactory(&created), WithPoolLeakDetection[*conn]())
+	checkerr(t, err)
+	defer p.Close()
+
+	c1, _ := p.Acquire(context.Background())
+	p.Release(c1)
+	p.Release(c1)
+	p.Release(&conn{id: -1})
+	if p.Idle() != 2 || p.Live() != 2 {
+		t.Fatalf("expect the extra releases ignored, but got idle %v, live %v", p.Idle(), p.Live())
+	}
+	a, _ := p.Acquire(context.Background())
+	b, _ := p.Acquire(context.Background())
+	if a == b {
+		t.Fatalf("expect two resources, but got %+v twice", a)
+	}
+}
+
+func TestPool_Leaks(t *testing.T) {
+	var created int32
+	p, err := NewPool(2, newConnFactory(&created), WithPoolLeakDetection[*conn]())
+	checkerr(t, err)
+	defer p.Close()
+
+	c1, _ := p.Acquire(context.Background())
+	c2, _ := p.Acquire(context.Background())
+	p.Release(c1)
+	if leaked := p.Leaks(0); len(leaked) != 1 || leaked[0] != c2 {
+		t.Fatalf("expect %+v leaked, but got %v", c2, leaked)
+	}
+	if leaked := p.Leaks(time.Hour); len(leaked) != 0 {
+		t.Fatalf("expect nothing leaked for an hour, but got %v", leaked)
+	}
+}
+
+func TestPool_NotComparable(t *testing.T) {
+	// a slice can't be a map key, it's fine without the leak detection.
+	p, err := NewPool(1, func() ([]byte, error) { return make([]byte, 0, 64), nil },
+		WithPoolResetter(func(buf []byte) { clear(buf[:cap(buf)]) }))
+	checkerr(t, err)
+	defer p.Close()
+
+	buf, err := p.Acquire(context.Background())
+	checkerr(t, err)
+	buf = append(buf, "hello"...)
+	p.Release(buf)
+	if buf, err = p.Acquire(context.Background()); err != nil || cap(buf) != 64 || string(buf) != "\x00\x00\x00\x00\x00" {
+		t.Fatalf("expect the buffer released and reset, but got %q, err: %v", buf, err)
+	}
+	p.Release(buf)
+	if leaked := p.Leaks(0); leaked != nil || p.Idle() != 1 {
+		t.Fatalf("expect nothing tracked and 1 idle, but got %v, %v idle", leaked, p.Idle())
+	}
+}
+
+func TestPool_Concurrent(t *testing.T) {
+	const workers, cnt = 8, 2000
+
+	var created int32
+	p, err := NewPool(3, newConnFactory(&created))
+	checkerr(t, err)
+	defer p.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var inUse, maxInUse int32
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < cnt; j++ {
+				c, err := p.Acquire(ctx)
+				if err != nil {
+					t.Errorf("acquire failed: %v", err)
+					return
+				}
+				if n := atomic.AddInt32(&inUse, 1); n > atomic.LoadInt32(&maxInUse) {
+					atomic.StoreInt32(&maxInUse, n)
+				}
+				atomic.AddInt32(&inUse, -1)
+				p.Release(c)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxInUse > 3 || created != 3 || p.Idle() != 3 {
+		t.Fatalf("expect at most 3 resources, but got %v in use, %v created, %v idle", maxInUse, created, p.Idle())
+	}
+}
+
+func TestNewPool_Errors(t *testing.T) {
+	if _, err := NewPool(0, newConnFactory(new(int32))); !errors.Is(err, ErrInvalidCapacity) {
+		t.Fatalf("expect ErrInvalidCapacity but got %v", err)
+	}
+
+	var destroyed int32
+	errFactory := errors.New("factory failed")
+	n := 0
+	_, err := NewPool(4, func() (*conn, error) {
+		if n++; n > 2 {
+			return nil, errFactory
+		}
+		return &conn{id: n}, nil
+	}, WithPoolDestroyer(func(*conn) { atomic.AddInt32(&destroyed, 1) }))
+	if !errors.Is(err, errFactory) || destroyed != 2 {
+		t.Fatalf("expect the factory error and 2 resources destroyed, but got %v, %v", err, destroyed)
+	}
+}
@@ -3,8 +3,10 @@ package mpmc
 import (
 	"fmt"
 	"net"
+	"reflect"
 	"runtime"
 	"sync/atomic"
+	"unsafe"
 
 	"github.com/hedzr/go-ringbuf/v2/mpmc/state"
 )
@@ -19,30 +21,183 @@ type ringBuf[T any] struct {
 	_          [CacheLinePadSize - 4]byte //nolint:revive
 	tail       uint32
 	_          [CacheLinePadSize - 4]byte //nolint:revive
-	putWaits   uint64
-	_          [CacheLinePadSize - 8]byte //nolint:revive
-	getWaits   uint64
-	_          [CacheLinePadSize - 8]byte //nolint:revive
-	data       []rbItem[T]
-	// debugMode  bool
-	// logger     log.Logger
-	// logger     *zap.Logger
-	// _         cpu.CacheLinePad
+	// active counts the in-flight operations per side, see
+	// [ringBuf.enter].
+	active [sides][activeShards]counter
+	data   []rbItem[T]
+	// metrics is nil unless [WithMetrics], see [ringBuf.Stats].
+	metrics atomic.Pointer[metrics]
+	// residence is nil unless [WithResidence].
+	residence atomic.Pointer[histogram]
+	// stamps are the enqueue times of the slots, nil unless
+	// [WithResidence], see [ringBuf.stampOf].
+	stamps []int64
+	// logger logs the notable events, see [WithLogger].
+	logger      *eventLogger
+	logEvery    uint64
 	initializer Initializeable[T]
+	notEmpty    notifier // wakes up the consumers parked in DequeueCtx
+	notFull     notifier // wakes up the producers parked in EnqueueCtx
+	closed      uint32
+	// resetting turns away the new operations while Reset is waiting
+	// for the in-flight ones.
+	resetting uint32
+	// waitStrategy is used in the retry loops and the blocking
+	// operations, nil means [ParkingWait].
+	waitStrategy WaitStrategy
+	// exactCapacity keeps the requested capacity rather than rounding
+	// it up, see [WithExactCapacity].
+	exactCapacity bool
+	// compact packs the slots without padding, see [WithCompactLayout].
+	compact bool
+	// zeroOnDequeue clears a slot once its element is taken, so that
+	// GC can reclaim the referenced objects, see [WithZeroOnDequeue].
+	// It's decided by T unless zeroSet.
+	zeroOnDequeue bool
+	zeroSet       bool
+	// shift locates the i-th slot at data[i<<shift], the items in
+	// between are unused for padding the slots to a cache line.
+	shift uint32
 }
 
+// rbItem is a slot of the ring buffer. It's not padded by itself,
+// see [ringBuf.alloc] for the layout.
 type rbItem[T any] struct {
 	readWrite uint64 // 0: writable, 1: readable, 2: write ok, 3: read ok
 	value     T      // ptr
-	_         [CacheLinePadSize - 8 - 8]byte
 	// _         cpu.CacheLinePad
 }
 
+// slotShift returns the shift of the slot indices, so that each of
+// the padded slots occupies at least one cache line.
+func slotShift[T any](compact bool) (shift uint32) {
+	if compact {
+		return
+	}
+	for size := unsafe.Sizeof(rbItem[T]{}); size<<shift < CacheLinePadSize; {
+		shift++
+	}
+	return
+}
+
+// alloc makes the backing array of size slots, the slots are padded
+// to the cache line unless [WithCompactLayout] is specified.
+func (rb *ringBuf[T]) alloc(size uint32) {
+	rb.setup()
+	rb.data = make([]rbItem[T], uint64(size)<<rb.shift)
+	rb.allocStamps(uint64(size))
+	rb.cap = size
+	rb.capModMask = size - 1 // = 2^n - 1
+	rb.preAlloc()
+}
+
+// setup resolves the layout options before allocating the slots.
+func (rb *ringBuf[T]) setup() {
+	rb.shift = slotShift[T](rb.compact)
+	if !rb.zeroSet {
+		rb.zeroOnDequeue = hasPointers(reflect.TypeFor[T]())
+	}
+}
+
+// hasPointers reports whether a value of type t references any
+// object which should be reclaimed by GC.
+func hasPointers(t reflect.Type) bool {
+	switch t.Kind() { //nolint:exhaustive
+	case reflect.Pointer, reflect.UnsafePointer, reflect.Map, reflect.Slice, reflect.String,
+		reflect.Chan, reflect.Func, reflect.Interface:
+		return true
+	case reflect.Array:
+		return t.Len() > 0 && hasPointers(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if hasPointers(t.Field(i).Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// at returns the slot at index.
+func (rb *ringBuf[T]) at(index uint32) *rbItem[T] {
+	return &rb.data[uint64(index)<<rb.shift]
+}
+
+// idle waits a moment before the n-th retry in the lock-free loops.
+func (rb *ringBuf[T]) idle(n int) {
+	rb.count(statYields, 1)
+	if rb.waitStrategy != nil {
+		rb.waitStrategy.Idle(n)
+		return
+	}
+	runtime.Gosched() // time to time
+}
+
+// claim waits till the state of a slot owned by us can be switched
+// from old to new, i.e. the previous owner has done with it.
+func (rb *ringBuf[T]) claim(holder *rbItem[T], old, new uint64) { //nolint:revive
+	for spins := 0; !atomic.CompareAndSwapUint64(&holder.readWrite, old, new); {
+		spins++
+		rb.idle(spins)
+	}
+}
+
+// store writes item into a slot claimed for writing.
+func (rb *ringBuf[T]) store(holder *rbItem[T], item T) {
+	rb.stamp(holder)
+	if rb.initializer != nil {
+		rb.initializer.CloneIn(item, &holder.value)
+	} else {
+		holder.value = item
+	}
+}
+
+// load reads the item from a slot claimed for reading.
+func (rb *ringBuf[T]) load(holder *rbItem[T]) (item T) {
+	rb.resided(holder)
+	if rb.initializer != nil {
+		item = rb.initializer.CloneOut(&holder.value)
+	} else {
+		item = holder.value
+		rb.wipe(holder)
+	}
+	return
+}
+
+// wipe clears a slot claimed for reading, if [WithZeroOnDequeue]. The
+// slot buffers of an [Initializeable] are kept for reuse.
+func (rb *ringBuf[T]) wipe(holder *rbItem[T]) {
+	if rb.zeroOnDequeue && rb.initializer == nil {
+		var zero T
+		holder.value = zero
+	}
+}
+
+// preAlloc pre-populates each slot by [Initializeable.PreAlloc], so
+// that CloneIn can copy into the slot buffers allocated once.
+func (rb *ringBuf[T]) preAlloc() {
+	if rb.initializer == nil {
+		return
+	}
+	for i := 0; i < len(rb.data)>>rb.shift; i++ {
+		rb.data[i<<rb.shift].value = rb.initializer.PreAlloc(i)
+	}
+}
+
 func (rb *ringBuf[T]) Put(item T) (err error) { return rb.Enqueue(item) } //nolint:revive
 
 func (rb *ringBuf[T]) Enqueue(item T) (err error) { //nolint:revive
+	if err = rb.enter(producing); err != nil {
+		return
+	}
+	defer rb.leave(producing)
+
 	var tail, head, nt uint32
 	var holder *rbItem[T]
+	if rb.IsClosed() {
+		err = ErrQueueClosed
+		return
+	}
 	for {
 		head = atomic.LoadUint32(&rb.head)
 		tail = atomic.LoadUint32(&rb.tail)
@@ -50,42 +205,36 @@ func (rb *ringBuf[T]) Enqueue(item T) (err error) { //nolint:revive
 
 		isFull := nt == head
 		if isFull {
-			err = ErrQueueFull
+			err = rb.full()
 			return
 		}
 		isEmpty := head == tail
 		if isEmpty && head == MaxUint32 {
-			err = ErrQueueNotReady
+			err = rb.notReady()
 			return
 		}
 
-		holder = &rb.data[tail]
-
-		atomic.CompareAndSwapUint32(&rb.tail, tail, nt)
-	retry:
-		if !atomic.CompareAndSwapUint64(&holder.readWrite, 0, 2) { //nolint:gomnd
-			if atomic.LoadUint64(&holder.readWrite) == 0 {
-				goto retry // sometimes, short circuit
-			}
-			runtime.Gosched() // time to time
-			continue
+		if !atomic.CompareAndSwapUint32(&rb.tail, tail, nt) {
+			rb.retried()
+			continue // tail CAS failed, retry with fresh values
 		}
+		holder = rb.at(tail)
+		// the slot is ours now, but a slower consumer might be still
+		// reading it.
+		rb.claim(holder, 0, 2) //nolint:gomnd
 
-		if rb.initializer != nil {
-			rb.initializer.CloneIn(item, &holder.value)
-		} else {
-			holder.value = item
-		}
+		rb.store(holder, item)
 		if !atomic.CompareAndSwapUint64(&holder.readWrite, 2, 1) { //nolint:gomnd
-			err = ErrRaced // runtime.Gosched() // never happens
+			err = rb.raced() // runtime.Gosched() // never happens
 		}
 
 		if state.VerboseEnabled {
 			state.Verbose("[W] enqueued",
 				"tail", tail, "new-tail", nt, "head", head, "value", toString(holder.value),
-				"value(rb.data[0])", toString(rb.data[0].value),
-				"value(rb.data[1])", toString(rb.data[1].value))
+				"value(rb.data[0])", toString(rb.at(0).value),
+				"value(rb.data[1])", toString(rb.at(1).value))
 		}
+		rb.produced(1, uint64(rb.qty(head, nt)))
 		return
 	}
 }
@@ -93,6 +242,11 @@ func (rb *ringBuf[T]) Enqueue(item T) (err error) { //nolint:revive
 func (rb *ringBuf[T]) Get() (item T, err error) { return rb.Dequeue() } //nolint:revive
 
 func (rb *ringBuf[T]) Dequeue() (item T, err error) { //nolint:revive
+	if err = rb.enter(consuming); err != nil {
+		return
+	}
+	defer rb.leave(consuming)
+
 	var tail, head, nh uint32
 	var holder *rbItem[T]
 	for {
@@ -106,40 +260,37 @@ func (rb *ringBuf[T]) Dequeue() (item T, err error) { //nolint:revive
 		isEmpty := head == tail
 		if isEmpty {
 			if head == MaxUint32 {
-				err = ErrQueueNotReady
+				err = rb.notReady()
+				return
+			}
+			if rb.IsClosed() {
+				err = ErrQueueClosed
 				return
 			}
-			err = ErrQueueEmpty
+			err = rb.empty()
 			return
 		}
 
-		holder = &rb.data[head]
-
 		nh = (head + 1) & rb.capModMask
-		atomic.CompareAndSwapUint32(&rb.head, head, nh)
-	retry:
-		if !atomic.CompareAndSwapUint64(&holder.readWrite, 1, 3) { //nolint:gomnd
-			if atomic.LoadUint64(&holder.readWrite) == 1 {
-				goto retry // sometimes, short circuit
-			}
-			runtime.Gosched() // time to time
-			continue
+		if !atomic.CompareAndSwapUint32(&rb.head, head, nh) {
+			rb.retried()
+			continue // head CAS failed, retry with fresh values
 		}
+		holder = rb.at(head)
+		// the slot is ours now, but a slower producer might be still
+		// writing it.
+		rb.claim(holder, 1, 3) //nolint:gomnd
 
-		if rb.initializer != nil {
-			item = rb.initializer.CloneOut(&holder.value)
-		} else {
-			item = holder.value
-			// holder.value = zero
-		}
+		item = rb.load(holder)
 		if !atomic.CompareAndSwapUint64(&holder.readWrite, 3, 0) { //nolint:gomnd
-			err = ErrRaced // runtime.Gosched() // never happens
+			err = rb.raced() // runtime.Gosched() // never happens
 		}
 
 		if state.VerboseEnabled {
 			state.Verbose("[ringbuf][GET] states are:",
 				"cap", rb.Cap(), "qty", rb.qty(head, tail), "tail", tail, "head", head, "new-head", nh, "item", toString(item))
 		}
+		rb.consumed(1)
 
 		// if item == nil {
 		// 	err = errors.New("[ringbuf][GET] cap: %v, qty: %v, head: %v, tail: %v, new head: %v", rb.cap, rb.qty(head, tail), head, tail, nh)
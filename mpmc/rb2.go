@@ -1,7 +1,6 @@
 package mpmc
 
 import (
-	"runtime"
 	"sync/atomic"
 
 	"github.com/hedzr/go-ringbuf/v2/mpmc/state"
@@ -13,122 +12,32 @@ type orbuf[T any] struct {
 
 func (rb *orbuf[T]) Put(item T) (err error) { return rb.Enqueue(item) } //nolint:revive
 
-func (rb *orbuf[T]) EnqueueM(item T) (overwrites uint32, err error) { //nolint:revive
-	var tail, head, nt, nh uint32
-	var holder *rbItem[T]
-	for {
-		head = atomic.LoadUint32(&rb.head)
-		tail = atomic.LoadUint32(&rb.tail)
-		nt = (tail + 1) & rb.capModMask
-
-		isEmpty := head == tail
-		if isEmpty && head == MaxUint32 {
-			err = ErrQueueNotReady
-			return
-		}
-
-		isFull := nt == head
-		if isFull {
-			nh = (head + 1) & rb.capModMask
-			atomic.CompareAndSwapUint32(&rb.head, head, nh)
-			overwrites++
-		}
-
-		if !atomic.CompareAndSwapUint32(&rb.tail, tail, nt) {
-			continue // tail CAS failed, retry with fresh values
-		}
-		holder = &rb.data[tail]
-	retry:
-		if !atomic.CompareAndSwapUint64(&holder.readWrite, 0, 2) { //nolint:gomnd
-			if !atomic.CompareAndSwapUint64(&holder.readWrite, 1, 2) { //nolint:gomnd
-				if atomic.LoadUint64(&holder.readWrite) == 0 {
-					goto retry // sometimes, short circuit
-				}
-				runtime.Gosched() // time to time
-				continue
-			}
-		}
-
-		if rb.initializer != nil {
-			rb.initializer.CloneIn(item, &holder.value)
-		} else {
-			holder.value = item
-		}
-		if !atomic.CompareAndSwapUint64(&holder.readWrite, 2, 1) { //nolint:gomnd
-			err = ErrRaced // runtime.Gosched() // never happens
-		}
+func (rb *orbuf[T]) Enqueue(item T) (err error) { //nolint:revive
+	_, _, err = rb.enqueue(item)
+	return
+}
 
-		if state.VerboseEnabled {
-			state.Verbose("[W] enqueued",
-				"tail", tail, "new-tail", nt, "head", head, "value", toString(holder.value),
-				"value(rb.data[0])", toString(rb.data[0].value),
-				"value(rb.data[1])", toString(rb.data[1].value))
-		}
-		return
-	}
+func (rb *orbuf[T]) EnqueueM(item T) (overwrites uint32, err error) { //nolint:revive
+	_, overwrites, err = rb.enqueue(item)
+	return
 }
 
 func (rb *orbuf[T]) EnqueueMRich(item T) (size, overwrites uint32, err error) { //nolint:revive
-	var tail, head, nt, nh uint32
-	var holder *rbItem[T]
-	for {
-		head = atomic.LoadUint32(&rb.head)
-		tail = atomic.LoadUint32(&rb.tail)
-		nt = (tail + 1) & rb.capModMask
-
-		isEmpty := head == tail
-		if isEmpty && head == MaxUint32 {
-			err = ErrQueueNotReady
-			return
-		}
-
-		isFull := nt == head
-		if isFull {
-			nh = (head + 1) & rb.capModMask
-			atomic.CompareAndSwapUint32(&rb.head, head, nh)
-			overwrites++
-			size = rb.qty(head, tail) + 1
-		}
-
-		if !atomic.CompareAndSwapUint32(&rb.tail, tail, nt) {
-			continue // tail CAS failed, retry with fresh values
-		}
-		holder = &rb.data[tail]
-	retry:
-		if !atomic.CompareAndSwapUint64(&holder.readWrite, 0, 2) { //nolint:gomnd
-			if !atomic.CompareAndSwapUint64(&holder.readWrite, 1, 2) { //nolint:gomnd
-				if atomic.LoadUint64(&holder.readWrite) == 0 {
-					goto retry // sometimes, short circuit
-				}
-				runtime.Gosched() // time to time
-				continue
-			}
-		}
-
-		if rb.initializer != nil {
-			rb.initializer.CloneIn(item, &holder.value)
-		} else {
-			holder.value = item
-		}
-		if !atomic.CompareAndSwapUint64(&holder.readWrite, 2, 1) { //nolint:gomnd
-			err = ErrRaced // runtime.Gosched() // never happens
-		}
-
-		if state.VerboseEnabled {
-			state.Verbose("[W] enqueued",
-				"tail", tail, "new-tail", nt, "head", head, "value", toString(holder.value),
-				"value(rb.data[0])", toString(rb.data[0].value),
-				"value(rb.data[1])", toString(rb.data[1].value))
-		}
+	return rb.enqueue(item)
+}
 
-		size = rb.qty(head, tail) + 1
+func (rb *orbuf[T]) enqueue(item T) (size, overwrites uint32, err error) { //nolint:revive
+	if err = rb.enter(producing); err != nil {
 		return
 	}
-}
+	defer rb.leave(producing)
 
-func (rb *orbuf[T]) Enqueue(item T) (err error) { //nolint:revive
 	var tail, head, nt, nh uint32
 	var holder *rbItem[T]
+	if rb.IsClosed() {
+		err = ErrQueueClosed
+		return
+	}
 	for {
 		head = atomic.LoadUint32(&rb.head)
 		tail = atomic.LoadUint32(&rb.tail)
@@ -136,46 +45,45 @@ func (rb *orbuf[T]) Enqueue(item T) (err error) { //nolint:revive
 
 		isEmpty := head == tail
 		if isEmpty && head == MaxUint32 {
-			err = ErrQueueNotReady
+			err = rb.notReady()
 			return
 		}
 
 		isFull := nt == head
 		if isFull {
 			nh = (head + 1) & rb.capModMask
-			atomic.CompareAndSwapUint32(&rb.head, head, nh)
+			if atomic.CompareAndSwapUint32(&rb.head, head, nh) {
+				overwrites++
+				rb.overwritten(1)
+			}
 		}
 
 		if !atomic.CompareAndSwapUint32(&rb.tail, tail, nt) {
+			rb.retried()
 			continue // tail CAS failed, retry with fresh values
 		}
-		holder = &rb.data[tail]
-	retry:
-		if !atomic.CompareAndSwapUint64(&holder.readWrite, 0, 2) { //nolint:gomnd
-			if !atomic.CompareAndSwapUint64(&holder.readWrite, 1, 2) { //nolint:gomnd
-				if atomic.LoadUint64(&holder.readWrite) == 0 {
-					goto retry // sometimes, short circuit
-				}
-				runtime.Gosched() // time to time
-				continue
-			}
+		holder = rb.at(tail)
+		// the slot is ours now. It's writable if it has been read,
+		// or it's overwritable if it's still unread.
+		for spins := 0; !atomic.CompareAndSwapUint64(&holder.readWrite, 0, 2) && //nolint:gomnd
+			!atomic.CompareAndSwapUint64(&holder.readWrite, 1, 2); { //nolint:gomnd
+			spins++
+			rb.idle(spins)
 		}
 
-		if rb.initializer != nil {
-			rb.initializer.CloneIn(item, &holder.value)
-		} else {
-			holder.value = item
-		}
+		rb.store(holder, item)
 		if !atomic.CompareAndSwapUint64(&holder.readWrite, 2, 1) { //nolint:gomnd
-			err = ErrRaced // runtime.Gosched() // never happens
+			err = rb.raced() // runtime.Gosched() // never happens
 		}
 
 		if state.VerboseEnabled {
 			state.Verbose("[W] enqueued",
 				"tail", tail, "new-tail", nt, "head", head, "value", toString(holder.value),
-				"value(rb.data[0])", toString(rb.data[0].value),
-				"value(rb.data[1])", toString(rb.data[1].value))
+				"value(rb.data[0])", toString(rb.at(0).value),
+				"value(rb.data[1])", toString(rb.at(1).value))
 		}
+		size = rb.qty(head, tail) + 1
+		rb.produced(1, uint64(min(size, rb.capModMask)))
 		return
 	}
 }
@@ -183,6 +91,11 @@ func (rb *orbuf[T]) Enqueue(item T) (err error) { //nolint:revive
 func (rb *orbuf[T]) Get() (item T, err error) { return rb.Dequeue() } //nolint:revive
 
 func (rb *orbuf[T]) Dequeue() (item T, err error) { //nolint:revive
+	if err = rb.enter(consuming); err != nil {
+		return
+	}
+	defer rb.leave(consuming)
+
 	var tail, head, nh uint32
 	var holder *rbItem[T]
 	for {
@@ -196,42 +109,60 @@ func (rb *orbuf[T]) Dequeue() (item T, err error) { //nolint:revive
 		isEmpty := head == tail
 		if isEmpty {
 			if head == MaxUint32 {
-				err = ErrQueueNotReady
+				err = rb.notReady()
+				return
+			}
+			if rb.IsClosed() {
+				err = ErrQueueClosed
 				return
 			}
-			err = ErrQueueEmpty
+			err = rb.empty()
 			return
 		}
 
 		nh = (head + 1) & rb.capModMask
 		if !atomic.CompareAndSwapUint32(&rb.head, head, nh) {
+			rb.retried()
 			continue // head CAS failed, retry with fresh values
 		}
-		holder = &rb.data[head]
-	retry:
-		if !atomic.CompareAndSwapUint64(&holder.readWrite, 1, 3) { //nolint:gomnd
-			if atomic.LoadUint64(&holder.readWrite) == 1 {
-				goto retry // sometimes, short circuit
-			}
-			runtime.Gosched() // time to time
+		holder = rb.at(head)
+		if !rb.take(holder) {
+			rb.overwritten(1) // taken back by the producers, it's gone
 			continue
 		}
 
-		if rb.initializer != nil {
-			item = rb.initializer.CloneOut(&holder.value)
-		} else {
-			item = holder.value
-			// holder.value = zero
-		}
+		item = rb.load(holder)
 		if !atomic.CompareAndSwapUint64(&holder.readWrite, 3, 0) { //nolint:gomnd
-			err = ErrRaced // runtime.Gosched() // never happens
+			err = rb.raced() // runtime.Gosched() // never happens
 		}
 
 		if state.VerboseEnabled {
 			state.Verbose("[ringbuf][GET] states are:",
 				"cap", rb.Cap(), "qty", rb.qty(head, tail), "tail", tail, "head", head, "new-head", nh, "item", toString(item))
 		}
+		rb.consumed(1)
 
 		return
 	}
 }
+
+// take claims the slot at the head just moved past, for reading. The
+// producers may lap the head and take the slot back meanwhile, so it
+// never waits on a free or readable slot: it waits only while the
+// slot is being written, or held by a peeker or another consumer,
+// which are let go anyway. It reports false if the slot is free,
+// i.e. the element has been overwritten and taken by someone else.
+func (rb *orbuf[T]) take(holder *rbItem[T]) bool {
+	for spins := 0; ; spins++ {
+		switch atomic.LoadUint64(&holder.readWrite) {
+		case 0:
+			return false
+		case 1:
+			if atomic.CompareAndSwapUint64(&holder.readWrite, 1, 3) { //nolint:gomnd
+				return true
+			}
+		default:
+			rb.idle(spins + 1)
+		}
+	}
+}
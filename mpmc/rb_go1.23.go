@@ -1,7 +1,68 @@
 package mpmc
 
-// func (rb *ringBuf[T]) Iterator(yield func(v T) bool) bool {
-// 	return
-// }
+import (
+	"errors"
+	"iter"
+)
+
+// All returns an iterator walking through the elements from head
+// to tail without consuming them.
+//
+// It takes a weakly consistent snapshot like [ringBuf.Snapshot]: the
+// range is fixed at the time the iteration starts, each element is
+// copied while its slot is held so it's never torn, and the slots
+// being written or taken away concurrently are skipped.
+func (rb *ringBuf[T]) All() iter.Seq[T] { return values(rb.Enumerate()) }
+
+func values[T any](seq iter.Seq2[uint32, T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, v := range seq {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Enumerate is like [ringBuf.All] but yields the logical position
+// of each element too, the head element is at position 0.
+func (rb *ringBuf[T]) Enumerate() iter.Seq2[uint32, T] {
+	return func(yield func(uint32, T) bool) {
+		o := rb.observe(false)
+		o.enumerate(yield)
+	}
+}
+
+// enumerate yields the elements observed, by their positions.
+func (o *observation[T]) enumerate(yield func(uint32, T) bool) {
+	for i, s := range o.slots[:o.size] {
+		if s.seen && !yield(uint32(i), s.value) {
+			return
+		}
+	}
+}
+
+// Drain returns an iterator which dequeues the elements as it
+// yields them, till the ring buffer is empty or the loop breaks.
+//
+// The element is dequeued only when it's about to be yielded, so
+// breaking the loop never loses anything.
+func (rb *ringBuf[T]) Drain() iter.Seq[T] { return drain[T](rb) }
+
+func (rb *orbuf[T]) Drain() iter.Seq[T] { return drain[T](rb) }
+
+func drain[T any](q dequeuer[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			v, err := q.Dequeue()
+			if err != nil && !errors.Is(err, ErrRaced) {
+				return
+			}
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
 
 func (rb *ringBuf[T]) Only123() string { return "OK, Go 1.23." }
@@ -1,6 +1,10 @@
 package mpmc
 
 import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"testing"
 )
 
@@ -21,3 +25,139 @@ func TestRingBuf_Only123(t *testing.T) { //nolint:revive
 
 	t.Logf("[go1.23] Test %v", q.Only123())
 }
+
+func TestRingBuf_All(t *testing.T) {
+	rb := New[int](8)
+	defer rb.Close()
+
+	for range rb.All() {
+		t.Fatal("expect nothing from an empty ring buffer")
+	}
+
+	// wrap the elements around the end of the buffer
+	for i := 0; i < 5; i++ {
+		_ = rb.Enqueue(i)
+		_, _ = rb.Dequeue()
+	}
+	for i := 0; i < 6; i++ {
+		checkerr(t, rb.Enqueue(i))
+	}
+
+	var got []int
+	for v := range rb.All() {
+		got = append(got, v)
+	}
+	if fmt.Sprint(got) != "[0 1 2 3 4 5]" {
+		t.Fatalf("unexpected elements: %v", got)
+	}
+
+	for pos, v := range rb.Enumerate() {
+		if int(pos) != v {
+			t.Fatalf("expect %v at position %v", v, pos)
+		}
+		if pos == 2 {
+			break
+		}
+	}
+
+	if rb.Size() != 6 {
+		t.Fatalf("expect All() non-destructive, but size is %v", rb.Size())
+	}
+}
+
+func TestRingBuf_Drain(t *testing.T) {
+	rb := New[int](8)
+	defer rb.Close()
+
+	for i := 0; i < 6; i++ {
+		checkerr(t, rb.Enqueue(i))
+	}
+
+	var got []int
+	for v := range rb.Drain() {
+		got = append(got, v)
+		if v == 2 {
+			break
+		}
+	}
+	if fmt.Sprint(got) != "[0 1 2]" || rb.Size() != 3 {
+		t.Fatalf("unexpected elements: %v, size: %v", got, rb.Size())
+	}
+
+	for v := range rb.Drain() {
+		got = append(got, v)
+	}
+	if fmt.Sprint(got) != "[0 1 2 3 4 5]" || !rb.IsEmpty() {
+		t.Fatalf("unexpected elements: %v, size: %v", got, rb.Size())
+	}
+
+	orb := NewOverlappedRingBuffer[int](4)
+	defer orb.Close()
+	for i := 0; i < 6; i++ {
+		checkerr(t, orb.Enqueue(i))
+	}
+	got = got[:0]
+	for v := range orb.Drain() {
+		got = append(got, v)
+	}
+	if fmt.Sprint(got) != "[3 4 5]" || !orb.IsEmpty() {
+		t.Fatalf("unexpected elements: %v, size: %v", got, orb.Size())
+	}
+}
+
+func TestRingBuf_All_Concurrent(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	type wide [8]uint64
+	for _, c := range []struct {
+		name string
+		rb   RingBuffer[wide]
+	}{
+		{"MPMC", New[wide](2)},
+		{"Overlapped", NewOverlappedRingBuffer[wide](2)},
+		{"Sequenced", NewSequenced[wide](2)},
+		{"MPSC", NewMPSC[wide](2)},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.rb
+			defer rb.Close()
+
+			var stop int32
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for i := uint64(1); atomic.LoadInt32(&stop) == 0; i++ {
+					var v wide
+					for j := range v {
+						v[j] = i
+					}
+					if rb.Enqueue(v) != nil {
+						runtime.Gosched()
+					}
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for atomic.LoadInt32(&stop) == 0 {
+					if _, err := rb.Dequeue(); err != nil {
+						runtime.Gosched()
+					}
+				}
+			}()
+
+			for n := 0; n < 20000; n++ {
+				for pos, v := range rb.Enumerate() {
+					for j := range v {
+						if v[j] != v[0] {
+							atomic.StoreInt32(&stop, 1)
+							wg.Wait()
+							t.Fatalf("torn element at position %v: %v", pos, v)
+						}
+					}
+				}
+			}
+			atomic.StoreInt32(&stop, 1)
+			wg.Wait()
+		})
+	}
+}
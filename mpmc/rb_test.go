@@ -732,3 +732,115 @@ func BenchmarkHello(b *testing.B) {
 		_ = fmt.Sprintf("hello, %s!", "world") //nolint:gosimple,gocritic
 	}
 }
+
+// TestRingBuf_NoLoss runs the producers and consumers at full speed,
+// so that a consumer often reaches a slot whose producer is still in
+// flight: each element must be taken exactly once.
+func TestRingBuf_NoLoss(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	const producers, consumers, count = 4, 4, 20000
+	for _, c := range []struct {
+		name string
+		rb   RingBuffer[int]
+	}{
+		{"MPMC", New[int](1 << 16)},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.rb
+			var seen [producers * count]int32
+			var taken, dups int64
+			deadline := time.Now().Add(10 * time.Second)
+			var wg sync.WaitGroup
+			for p := 0; p < producers; p++ {
+				wg.Add(1)
+				go func(p int) {
+					defer wg.Done()
+					for i := 0; i < count; {
+						if rb.Enqueue(p*count+i) == nil {
+							i++
+						} else {
+							runtime.Gosched()
+						}
+					}
+				}(p)
+			}
+			for q := 0; q < consumers; q++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for atomic.LoadInt64(&taken) < producers*count && time.Now().Before(deadline) {
+						it, err := rb.Dequeue()
+						if err != nil {
+							runtime.Gosched()
+							continue
+						}
+						if atomic.AddInt32(&seen[it], 1) > 1 {
+							atomic.AddInt64(&dups, 1)
+						}
+						atomic.AddInt64(&taken, 1)
+					}
+				}()
+			}
+			wg.Wait()
+			if taken != producers*count || dups != 0 {
+				t.Fatalf("expect %v elements taken once, but got %v taken, %v duplicated", producers*count, taken, dups)
+			}
+		})
+	}
+}
+
+// TestOverlapped_NoDup runs the overwriting producers and consumers at
+// full speed on a small ring, so that the producers often lap the
+// consumers. The elements may be lost, but each one taken must have
+// been put and taken once, and the consumers must not hang on the
+// slots taken back by the producers.
+func TestOverlapped_NoDup(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	const producers, consumers, count = 4, 4, 20000
+	rb := NewOverlappedRingBuffer[int](64)
+	defer rb.Close()
+
+	var seen [producers * count]int32
+	var dups int64
+	var pwg, cwg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		pwg.Add(1)
+		go func(p int) {
+			defer pwg.Done()
+			for i := 0; i < count; i++ {
+				checkerr(t, rb.Enqueue(p*count+i))
+			}
+		}(p)
+	}
+	var done atomic.Bool
+	for q := 0; q < consumers; q++ {
+		cwg.Add(1)
+		go func() {
+			defer cwg.Done()
+			for {
+				it, err := rb.Dequeue()
+				if err != nil {
+					if done.Load() {
+						return
+					}
+					runtime.Gosched()
+					continue
+				}
+				if it < 0 || it >= producers*count {
+					t.Errorf("unexpected element %v", it)
+					return
+				}
+				if atomic.AddInt32(&seen[it], 1) > 1 {
+					atomic.AddInt64(&dups, 1)
+				}
+			}
+		}()
+	}
+	pwg.Wait()
+	done.Store(true)
+	cwg.Wait()
+
+	if dups != 0 {
+		t.Fatalf("expect each element taken once at most, but got %v duplicated", dups)
+	}
+}
@@ -0,0 +1,154 @@
+package mpmc
+
+import (
+	"sync/atomic"
+)
+
+// side is the kind of an operation. The in-flight operations are
+// counted per side, so that the producers and the consumers don't
+// write to a shared counter, and each side is sharded by
+// [stackShard], so that the producers, or the consumers, running on
+// the different CPUs don't either.
+type side int
+
+const (
+	producing side = iota
+	consuming
+	observing // Peek, Snapshot, Dump and so on
+	sides
+)
+
+// activeShards is the number of the shards of a side. It's fixed,
+// so that the counters take no allocation: a few shards take most of
+// the contention away, even with more CPUs.
+const activeShards = 8
+
+// counter is a cache line padded counter.
+type counter struct {
+	n int32
+	_ [CacheLinePadSize - 4]byte
+}
+
+// enter registers an in-flight operation of the side s. It fails with
+// [ErrQueueNotReady] while the ring buffer is being reset, so that
+// the caller can retry later.
+func (rb *ringBuf[T]) enter(s side) error {
+	if atomic.LoadUint32(&rb.resetting) != 0 {
+		return rb.notReady() // don't disturb the counter meanwhile
+	}
+	n := &rb.active[s][stackShard(activeShards-1)].n
+	atomic.AddInt32(n, 1)
+	if atomic.LoadUint32(&rb.resetting) != 0 {
+		atomic.AddInt32(n, -1)
+		return rb.notReady()
+	}
+	return nil
+}
+
+// leave unregisters an operation of the side s. The shard might not
+// be the one counted by enter, if the stack has been moved or the
+// ticket is passed back by another goroutine, which is fine since
+// only the sum of the shards matters, see [ringBuf.busy].
+func (rb *ringBuf[T]) leave(s side) {
+	atomic.AddInt32(&rb.active[s][stackShard(activeShards-1)].n, -1)
+}
+
+// busy reports whether any operation is in flight. A shard might be
+// negative, and the sum of a side might wrap around, but it's zero
+// only if every operation entered has left: an operation let in has
+// counted itself before quiesce raised the resetting flag, so the
+// shards are read after all of the increments.
+func (rb *ringBuf[T]) busy() bool {
+	for s := range rb.active {
+		var n int32
+		for i := range rb.active[s] {
+			n += atomic.LoadInt32(&rb.active[s][i].n)
+		}
+		if n != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// quiesce turns away the new operations and waits for the in-flight
+// ones, including the reservations and acquisitions which have not
+// been committed or released yet.
+func (rb *ringBuf[T]) quiesce() {
+	for spins := 0; !atomic.CompareAndSwapUint32(&rb.resetting, 0, 1); {
+		spins++
+		rb.idle(spins) // another one is resetting
+	}
+	for spins := 0; rb.busy(); {
+		spins++
+		rb.idle(spins)
+	}
+}
+
+// resume lets the operations in again, and wakes up the parked
+// producers since the slots have been freed.
+func (rb *ringBuf[T]) resume() {
+	atomic.StoreUint32(&rb.resetting, 0)
+	rb.notFull.broadcast()
+}
+
+// clearFunc quiesces rb, passes the elements found by scan to f, and
+// clears the slots by reset.
+func clearFunc[T any](rb *ringBuf[T], scan func(o *observation[T], all bool), reset func(), f func(T)) {
+	rb.quiesce()
+	defer rb.resume()
+	if f != nil {
+		var o observation[T]
+		scan(&o, false)
+		for _, v := range o.items() {
+			f(v)
+		}
+	}
+	reset()
+}
+
+// collect returns the elements passed by clear.
+func collect[T any](clear func(f func(T))) (items []T) {
+	clear(func(v T) { items = append(items, v) })
+	return
+}
+
+// Reset clears the ring buffer. It's safe while the producers and
+// consumers are running: the operations started in the meantime
+// fail with [ErrQueueNotReady], and Reset waits for the in-flight
+// ones, so a pending Reserve or Acquire delays it till Commit or
+// Release.
+//
+// The referenced values are released, and the slots are pre-populated
+// again if an [Initializeable] has been specified.
+func (rb *ringBuf[T]) Reset() { rb.ClearFunc(nil) }
+
+// Clear is like Reset, and returns the elements removed.
+func (rb *ringBuf[T]) Clear() []T { return collect(rb.ClearFunc) }
+
+// ClearFunc is like Reset, and passes the elements removed to f from
+// head to tail. f must not operate on the ring buffer, which is not
+// ready till ClearFunc returns.
+func (rb *ringBuf[T]) ClearFunc(f func(T)) { clearFunc(rb, rb.scan, rb.reset, f) }
+
+func (rb *topoRingBuf[T]) Reset()              { rb.ClearFunc(nil) }                            //nolint:revive
+func (rb *topoRingBuf[T]) Clear() []T          { return collect(rb.ClearFunc) }                 //nolint:revive
+func (rb *topoRingBuf[T]) ClearFunc(f func(T)) { clearFunc(&rb.ringBuf, rb.scan, rb.reset, f) } //nolint:revive
+
+func (rb *seqRingBuf[T]) Reset()              { rb.ClearFunc(nil) }                            //nolint:revive
+func (rb *seqRingBuf[T]) Clear() []T          { return collect(rb.ClearFunc) }                 //nolint:revive
+func (rb *seqRingBuf[T]) ClearFunc(f func(T)) { clearFunc(&rb.ringBuf, rb.scan, rb.reset, f) } //nolint:revive
+
+// Reset clears the ring buffer, see also [ringBuf.Reset].
+func (g *growRingBuf[T]) Reset() { g.ClearFunc(nil) }
+
+func (g *growRingBuf[T]) Clear() []T { return collect(g.ClearFunc) } //nolint:revive
+
+// ClearFunc clears the ring buffer, see also [ringBuf.ClearFunc]. It
+// waits for the resizing in progress.
+func (g *growRingBuf[T]) ClearFunc(f func(T)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.cur.Load().rb.ClearFunc(f)
+	g.notFull.broadcast()
+}
@@ -0,0 +1,152 @@
+package mpmc
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClear(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		create func(capacity uint32, opts ...Opt[int]) RingBuffer[int]
+	}{
+		{"MPMC", New[int]},
+		{"SPSC", NewSPSC[int]},
+		{"MPSC", NewMPSC[int]},
+		{"Sequenced", NewSequenced[int]},
+		{"Overlapped", func(capacity uint32, opts ...Opt[int]) RingBuffer[int] {
+			return NewOverlappedRingBuffer(capacity, opts...)
+		}},
+		{"Growable", func(capacity uint32, opts ...Opt[int]) RingBuffer[int] {
+			return NewGrowable(capacity, capacity, opts...)
+		}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.create(8)
+			defer rb.Close()
+
+			for i := 0; i < 5; i++ {
+				checkerr(t, rb.Enqueue(i))
+			}
+			if _, err := rb.Dequeue(); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if got := rb.Clear(); !reflect.DeepEqual(got, []int{1, 2, 3, 4}) {
+				t.Fatalf("expect [1 2 3 4] cleared, but got %v", got)
+			}
+			if !rb.IsEmpty() {
+				t.Fatalf("expect empty after Clear, but got %v", rb)
+			}
+
+			checkerr(t, rb.Enqueue(5))
+			var seen []int
+			rb.ClearFunc(func(v int) { seen = append(seen, v) })
+			if !reflect.DeepEqual(seen, []int{5}) || !rb.IsEmpty() {
+				t.Fatalf("expect [5] cleared, but got %v, %v", seen, rb)
+			}
+			if got := rb.Clear(); got != nil {
+				t.Fatalf("expect nothing cleared, but got %v", got)
+			}
+		})
+	}
+}
+
+// TestReset_Concurrent clears the ring buffer repeatedly while the
+// producers and consumers are running, every element must be either
+// dequeued or cleared exactly once.
+func TestReset_Concurrent(t *testing.T) {
+	const producers, consumers, count = 4, 4, 2000
+	rb := NewSequenced[int](64)
+	defer rb.Close()
+
+	var notReady, dequeued, cleared int64
+	var pwg, cwg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		pwg.Add(1)
+		go func() {
+			defer pwg.Done()
+			for i := 0; i < count; {
+				switch err := rb.Enqueue(i); {
+				case err == nil:
+					i++
+				case errors.Is(err, ErrQueueNotReady):
+					atomic.AddInt64(&notReady, 1)
+					fallthrough
+				default:
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+	var done atomic.Bool
+	for q := 0; q < consumers; q++ {
+		cwg.Add(1)
+		go func() {
+			defer cwg.Done()
+			for !done.Load() {
+				if _, err := rb.Dequeue(); err == nil {
+					atomic.AddInt64(&dequeued, 1)
+				} else {
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 200; i++ {
+		atomic.AddInt64(&cleared, int64(len(rb.Clear())))
+		runtime.Gosched()
+	}
+	pwg.Wait()
+	done.Store(true)
+	cwg.Wait()
+	cleared += int64(len(rb.Clear()))
+
+	if total := dequeued + cleared; total != producers*count {
+		t.Fatalf("expect %d elements, but got %d dequeued + %d cleared", producers*count, dequeued, cleared)
+	}
+	t.Logf("%d dequeued, %d cleared, %d not ready", dequeued, cleared, notReady)
+}
+
+// TestReset_Pending holds a slot on either side, Reset must wait for
+// it, no matter which of the per-side counters it's in.
+func TestReset_Pending(t *testing.T) {
+	for _, c := range topologies {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.create(8)
+			defer rb.Close()
+
+			checkerr(t, rb.Enqueue(1))
+			_, reserved, err := rb.Reserve()
+			checkerr(t, err)
+			_, acquired, err := rb.Acquire()
+			checkerr(t, err)
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				rb.Reset()
+			}()
+			for _, finish := range []func(){
+				func() { rb.Commit(reserved) },
+				func() { rb.Release(acquired) },
+			} {
+				runtime.Gosched()
+				select {
+				case <-done:
+					t.Fatal("expect Reset to wait for the pending reservation and acquisition")
+				default:
+				}
+				finish()
+			}
+			<-done
+			if !rb.IsEmpty() {
+				t.Fatalf("expect empty after Reset, but got %v", rb)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package mpmc
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// Residence summarizes how long the elements stayed in the ring
+// buffer, from being put to being taken, see [WithResidence].
+//
+// The quantiles are the upper bounds of the histogram buckets, which
+// are within about 6% of the recorded durations.
+type Residence struct {
+	Count uint64        // the elements taken and recorded
+	Sum   time.Duration // the total residence time of them
+	P50   time.Duration
+	P99   time.Duration
+	P999  time.Duration
+	Max   time.Duration
+}
+
+// WithResidence records the residence time of each element, i.e. the
+// time elapsed from being enqueued (or committed) to being dequeued
+// (or released), into [Stats].Residence.
+//
+// Each slot is stamped by the monotonic clock on enqueue, the stamps
+// are kept in an array of their own rather than in the slots, and the
+// durations are counted into a lock-free log-linear histogram, an
+// operation costs a clock reading and an atomic add or two. The
+// elements overwritten, cleared or reset are not recorded.
+func WithResidence[T any]() Opt[T] {
+	return func(buf *ringBuf[T]) {
+		buf.residence.Store(new(histogram))
+	}
+}
+
+// epoch is the base of the slot stamps, which are read from the
+// monotonic clock by [time.Since].
+var epoch = time.Now()
+
+func now() int64 { return int64(time.Since(epoch)) }
+
+const (
+	// histSubBits splits each power of two range into 16 linear
+	// buckets.
+	histSubBits = 4
+	histSub     = 1 << histSubBits
+	histBuckets = (64 - histSubBits + 1) * histSub
+)
+
+// histogram counts the durations in nanoseconds by the log-linear
+// buckets, in the manner of HdrHistogram: the values below histSub
+// have their own buckets, and each power of two range above is split
+// into histSub buckets.
+type histogram struct {
+	counts [histBuckets]uint64
+	sum    uint64
+	max    uint64
+}
+
+// bucketOf returns the bucket index of v.
+func bucketOf(v uint64) int {
+	if v < histSub {
+		return int(v)
+	}
+	shift := bits.Len64(v) - histSubBits - 1
+	return (shift+1)*histSub + int(v>>shift) - histSub
+}
+
+// bucketLow returns the lowest value counted by the bucket i.
+func bucketLow(i int) uint64 {
+	if i < histSub {
+		return uint64(i)
+	}
+	shift := i/histSub - 1
+	return uint64(i%histSub+histSub) << shift
+}
+
+// record counts a duration of d nanoseconds.
+func (h *histogram) record(d int64) {
+	v := uint64(max(d, 0))
+	atomic.AddUint64(&h.counts[bucketOf(v)], 1)
+	atomic.AddUint64(&h.sum, v)
+	for m := atomic.LoadUint64(&h.max); v > m; m = atomic.LoadUint64(&h.max) {
+		if atomic.CompareAndSwapUint64(&h.max, m, v) {
+			return
+		}
+	}
+}
+
+// summary takes the quantiles from a snapshot of the buckets.
+func (h *histogram) summary() (r Residence) {
+	var counts [histBuckets]uint64
+	for i := range counts {
+		counts[i] = atomic.LoadUint64(&h.counts[i])
+		r.Count += counts[i]
+	}
+	r.Sum = time.Duration(atomic.LoadUint64(&h.sum))
+	r.Max = time.Duration(atomic.LoadUint64(&h.max))
+	if r.Count == 0 {
+		return
+	}
+
+	quantiles := []struct {
+		q   float64
+		dst *time.Duration
+	}{{0.5, &r.P50}, {0.99, &r.P99}, {0.999, &r.P999}}
+	var seen uint64
+	i := 0
+	for _, x := range quantiles {
+		rank := uint64(x.q*float64(r.Count-1)) + 1
+		for ; seen+counts[i] < rank; i++ {
+			seen += counts[i]
+		}
+		v := time.Duration(bucketLow(i+1) - 1)
+		if i+1 == histBuckets {
+			v = r.Max
+		}
+		*x.dst = min(v, r.Max)
+	}
+	return
+}
+
+// reset zeroes the histogram.
+func (h *histogram) reset() {
+	for i := range h.counts {
+		atomic.StoreUint64(&h.counts[i], 0)
+	}
+	atomic.StoreUint64(&h.sum, 0)
+	atomic.StoreUint64(&h.max, 0)
+}
+
+// allocStamps makes the stamps of n slots, if [WithResidence]. They
+// are apart from the slots, which stay as small as they are without
+// it, see also [WithCompactLayout].
+func (rb *ringBuf[T]) allocStamps(n uint64) {
+	if rb.residence.Load() != nil {
+		rb.stamps = make([]int64, n)
+	}
+}
+
+// stampOf returns the stamp of a slot, or nil unless [WithResidence].
+// The slot index is told by the offset of the slot in the backing
+// array.
+func (rb *ringBuf[T]) stampOf(holder *rbItem[T]) *int64 {
+	if rb.stamps == nil {
+		return nil
+	}
+	off := uintptr(unsafe.Pointer(holder)) - uintptr(unsafe.Pointer(unsafe.SliceData(rb.data)))
+	return &rb.stamps[off/unsafe.Sizeof(*holder)>>rb.shift]
+}
+
+// stamp stamps a slot claimed for writing, if [WithResidence].
+func (rb *ringBuf[T]) stamp(holder *rbItem[T]) {
+	if p := rb.stampOf(holder); p != nil && rb.residence.Load() != nil {
+		*p = now()
+	}
+}
+
+// resided records the residence time of the element in a slot claimed
+// for reading, if [WithResidence].
+func (rb *ringBuf[T]) resided(holder *rbItem[T]) {
+	if p := rb.stampOf(holder); p != nil && *p != 0 {
+		if h := rb.residence.Load(); h != nil {
+			h.record(now() - *p)
+		}
+	}
+}
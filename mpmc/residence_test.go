@@ -0,0 +1,163 @@
+package mpmc
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestHistogram(t *testing.T) {
+	for _, v := range []uint64{0, 1, 15, 16, 17, 31, 32, 1000, 123456789, 1 << 40, 1<<64 - 1} {
+		i := bucketOf(v)
+		if low := bucketLow(i); v < low || i+1 < histBuckets && v >= bucketLow(i+1) {
+			t.Fatalf("%v is out of the bucket #%v from %v", v, i, low)
+		}
+		if low := bucketLow(i); v >= histSub && float64(v-low) > float64(v)/histSub {
+			t.Fatalf("the bucket #%v from %v is too wide for %v", i, low, v)
+		}
+	}
+
+	var h histogram
+	for i := int64(1); i <= 1000; i++ {
+		h.record(i * int64(time.Microsecond))
+	}
+	r := h.summary()
+	if r.Count != 1000 || r.Max != time.Millisecond || r.Sum != 500500*time.Microsecond {
+		t.Fatalf("unexpected summary: %+v", r)
+	}
+	for _, x := range []struct {
+		got, want time.Duration
+	}{{r.P50, 500 * time.Microsecond}, {r.P99, 990 * time.Microsecond}, {r.P999, 999 * time.Microsecond}} {
+		if x.got < x.want || x.got > x.want+x.want/histSub {
+			t.Fatalf("expect about %v but got %v, summary: %+v", x.want, x.got, r)
+		}
+	}
+
+	h.reset()
+	if r = h.summary(); r != (Residence{}) {
+		t.Fatalf("expect the histogram reset, but got %+v", r)
+	}
+}
+
+func TestResidence(t *testing.T) {
+	const delay = 2 * time.Millisecond
+	for _, c := range allCreators(4) {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.create(8, WithResidence[int]())
+			defer rb.Close()
+
+			checkerr(t, rb.Enqueue(1))
+			ptr, ticket, err := rb.Reserve()
+			checkerr(t, err)
+			*ptr = 2
+			rb.Commit(ticket)
+			time.Sleep(delay)
+
+			_, err = rb.Dequeue()
+			checkerr(t, err)
+			_, ticket, err = rb.Acquire()
+			checkerr(t, err)
+			rb.Release(ticket)
+			if r := rb.Stats().Residence; r.Count != 2 || r.P50 < delay || r.Max < r.P50 || r.Sum < 2*delay {
+				t.Fatalf("unexpected residence: %+v", r)
+			}
+
+			rb.ResetCounters()
+			if r := rb.Stats().Residence; r != (Residence{}) {
+				t.Fatalf("expect the residence reset, but got %+v", r)
+			}
+		})
+	}
+}
+
+func TestResidence_Growable(t *testing.T) {
+	rb := NewGrowable[int](4, 64, WithResidence[int]())
+	defer rb.Close()
+
+	for i := 0; i < 3; i++ {
+		checkerr(t, rb.Enqueue(i))
+	}
+	time.Sleep(2 * time.Millisecond)
+	checkerr(t, rb.Resize(16))
+	if r := rb.Stats().Residence; r.Count != 0 {
+		t.Fatalf("expect the moved elements not recorded, but got %+v", r)
+	}
+	for i := 0; i < 3; i++ {
+		_, err := rb.Dequeue()
+		checkerr(t, err)
+	}
+	// the elements keep their stamps across resizing.
+	if r := rb.Stats().Residence; r.Count != 3 || r.P50 < 2*time.Millisecond {
+		t.Fatalf("unexpected residence after resizing: %+v", r)
+	}
+}
+
+func TestResidence_Concurrent(t *testing.T) {
+	const producers, n = 4, 2000
+	rb := New[int](64, WithResidence[int]())
+	defer rb.Close()
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; {
+				if rb.Enqueue(i) == nil {
+					i++
+				} else {
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+	for taken := 0; taken < producers*n; {
+		if _, err := rb.Dequeue(); err == nil {
+			taken++
+		} else {
+			runtime.Gosched()
+		}
+	}
+	wg.Wait()
+	if r := rb.Stats().Residence; r.Count != producers*n || r.P50 > r.P99 || r.P99 > r.P999 || r.P999 > r.Max {
+		t.Fatalf("unexpected residence: %+v", r)
+	}
+	if s := New[int](8).Stats(); s.Residence != (Residence{}) {
+		t.Fatalf("expect no residence without the option, but got %+v", s.Residence)
+	}
+}
+
+// TestResidence_Layout checks the stamps are kept apart from the
+// slots, which stay as small as they are without the option.
+func TestResidence_Layout(t *testing.T) {
+	if size := unsafe.Sizeof(rbItem[uint32]{}); size != 16 {
+		t.Fatalf("expect 16 bytes per compact slot for uint32, but got %v", size)
+	}
+	if rb := New[int](8).(*ringBuf[int]); rb.stamps != nil {
+		t.Fatalf("expect no stamps without the option, but got %v", len(rb.stamps))
+	}
+
+	rb := New[int](5, WithResidence[int](), WithExactCapacity[int]()).(*seqRingBuf[int])
+	if len(rb.stamps) != 5 {
+		t.Fatalf("expect a stamp per slot, but got %v", len(rb.stamps))
+	}
+	for lap := 0; lap < 3; lap++ {
+		for i := 0; i < 5; i++ {
+			checkerr(t, rb.Enqueue(i))
+		}
+		for i, p := range rb.stamps {
+			if p == 0 || rb.stampOf(rb.slot(uint64(lap*5+i))) != &rb.stamps[i] {
+				t.Fatalf("expect the slot #%v stamped, but got %v", i, rb.stamps)
+			}
+		}
+		for i := 0; i < 5; i++ {
+			_, err := rb.Dequeue()
+			checkerr(t, err)
+		}
+	}
+	if r := rb.Stats().Residence; r.Count != 15 {
+		t.Fatalf("expect 15 elements recorded, but got %+v", r)
+	}
+}
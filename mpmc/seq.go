@@ -0,0 +1,371 @@
+package mpmc
+
+import (
+	"context"
+	"iter"
+	"math"
+	"sync/atomic"
+	"unsafe"
+)
+
+// NewSequenced returns a bounded MPMC ring buffer based on Dmitry
+// Vyukov's algorithm.
+//
+// Each slot carries a sequence number which increases monotonically
+// by the lap, and the producers and consumers claim a slot by its
+// 64-bit position rather than a wrapped index, so there is no ABA
+// problem and [ErrRaced] never happens. All of the slots are usable,
+// so Cap() equals CapReal().
+//
+// The capacity is rounded up to a power of 2, unless
+// [WithExactCapacity] is specified.
+func NewSequenced[T any](capacity uint32, opts ...Opt[T]) (ringBuffer RingBuffer[T]) {
+	return newRingBuffer(func(capacity uint32, opts ...Opt[T]) (ringBuffer RingBuffer[T]) {
+		if rb, err := newSeqRingBuf(uint64(capacity), opts...); err == nil {
+			ringBuffer = rb
+		}
+		return
+	}, capacity, opts...)
+}
+
+// maxAlloc is the most bytes which a slice can be allocated with,
+// i.e. the addressable heap of the Go runtime.
+const maxAlloc = min(1<<47, math.MaxInt) //nolint:gomnd
+
+// maxSlots returns the most slots which can be allocated, with the
+// padding of shift and the stamps if stamped.
+func maxSlots[T any](shift uint32, stamped bool) uint64 {
+	perSlot := uint64(unsafe.Sizeof(rbItem[T]{})) << shift
+	if stamped {
+		perSlot += 8 //nolint:gomnd // an int64 stamp
+	}
+	return maxAlloc / perSlot
+}
+
+func newSeqRingBuf[T any](capacity uint64, opts ...Opt[T]) (rb *seqRingBuf[T], err error) {
+	rb = &seqRingBuf[T]{}
+	for _, opt := range opts {
+		opt(&rb.ringBuf)
+	}
+	size := capacity
+	if !rb.exactCapacity {
+		size = roundUpToPower2x64(capacity)
+	}
+	rb.setup()
+	if size == 0 || size > maxSlots[T](rb.shift, rb.residence.Load() != nil) {
+		err = ErrInvalidCapacity
+		return
+	}
+	rb.data = make([]rbItem[T], size<<rb.shift)
+	rb.allocStamps(size)
+	rb.size, rb.mask = size, size-1 // mask is unused in exact capacity mode
+	rb.cap = uint32(min(size, uint64(MaxUint32)))
+	rb.reset()
+	return
+}
+
+// seqRingBuf is a sequenced ring buffer, the readWrite field of a
+// slot holds its sequence number:
+//
+//   - seq == pos: the slot is free for the producer at pos;
+//   - seq == pos+1: the slot has been published, and it's ready
+//     for the consumer at pos;
+//   - seq == pos+cap: the slot has been released by the consumer,
+//     and it's ready for the producer in the next lap.
+//
+// A peeker holds a published slot by setting [peekedSeq] in seq, the
+// consumer claiming it waits till the bit is cleared.
+type seqRingBuf[T any] struct {
+	ringBuf[T]
+	size   uint64 // the number of slots, rb.cap is clamped to MaxUint32
+	mask   uint64 // = size - 1
+	_      [CacheLinePadSize]byte
+	enqPos uint64
+	_      [CacheLinePadSize - 8]byte //nolint:revive
+	deqPos uint64
+	_      [CacheLinePadSize - 8]byte //nolint:revive
+}
+
+// peekedSeq is set in the sequence number of a slot held by a peeker.
+const peekedSeq = uint64(1) << 62
+
+// loadSeq returns the sequence number of a slot, ignoring [peekedSeq].
+func (rb *seqRingBuf[T]) loadSeq(holder *rbItem[T]) uint64 {
+	return atomic.LoadUint64(&holder.readWrite) &^ peekedSeq
+}
+
+// await waits till a peeker has done with the claimed slot whose
+// sequence number is seq.
+func (rb *seqRingBuf[T]) await(holder *rbItem[T], seq uint64) {
+	for spins := 0; atomic.LoadUint64(&holder.readWrite) != seq; {
+		spins++
+		rb.idle(spins)
+	}
+}
+
+// slot returns the slot for the 64-bit position pos.
+func (rb *seqRingBuf[T]) slot(pos uint64) *rbItem[T] {
+	if rb.exactCapacity {
+		return &rb.data[(pos%rb.size)<<rb.shift]
+	}
+	return &rb.data[(pos&rb.mask)<<rb.shift]
+}
+
+func (rb *seqRingBuf[T]) Put(item T) (err error) { return rb.Enqueue(item) } //nolint:revive
+
+func (rb *seqRingBuf[T]) Enqueue(item T) (err error) { //nolint:revive
+	if err = rb.enter(producing); err != nil {
+		return
+	}
+	defer rb.leave(producing)
+
+	if rb.IsClosed() {
+		err = ErrQueueClosed
+		return
+	}
+
+	var holder *rbItem[T]
+	pos := atomic.LoadUint64(&rb.enqPos)
+	for {
+		if pos&sealedPos != 0 {
+			err = rb.full() // sealed, the producers move on to the next segment
+			return
+		}
+		holder = rb.slot(pos)
+		seq := rb.loadSeq(holder)
+		if dif := int64(seq - pos); dif == 0 {
+			if atomic.CompareAndSwapUint64(&rb.enqPos, pos, pos+1) {
+				break
+			}
+			rb.retried()
+			pos = atomic.LoadUint64(&rb.enqPos)
+		} else if dif < 0 {
+			err = rb.full() // not released by the consumer of the last lap
+			return
+		} else {
+			pos = atomic.LoadUint64(&rb.enqPos) // claimed by another producer
+		}
+	}
+
+	rb.store(holder, item)
+	atomic.StoreUint64(&holder.readWrite, pos+1)
+	rb.produced(1, pos+1)
+	return
+}
+
+func (rb *seqRingBuf[T]) Get() (item T, err error) { return rb.Dequeue() } //nolint:revive
+
+func (rb *seqRingBuf[T]) Dequeue() (item T, err error) { //nolint:revive
+	if err = rb.enter(consuming); err != nil {
+		return
+	}
+	defer rb.leave(consuming)
+
+	var holder *rbItem[T]
+	pos := atomic.LoadUint64(&rb.deqPos)
+	for {
+		holder = rb.slot(pos)
+		seq := rb.loadSeq(holder)
+		if dif := int64(seq - (pos + 1)); dif == 0 {
+			if atomic.CompareAndSwapUint64(&rb.deqPos, pos, pos+1) {
+				break
+			}
+			rb.retried()
+			pos = atomic.LoadUint64(&rb.deqPos)
+		} else if dif < 0 {
+			err = rb.errEmpty() // not published by the producer yet
+			return
+		} else {
+			pos = atomic.LoadUint64(&rb.deqPos) // claimed by another consumer
+		}
+	}
+
+	rb.await(holder, pos+1)
+	item = rb.load(holder)
+	atomic.StoreUint64(&holder.readWrite, pos+rb.size)
+	rb.consumed(1)
+	return
+}
+
+func (rb *seqRingBuf[T]) errEmpty() error {
+	if rb.IsClosed() {
+		return ErrQueueClosed
+	}
+	return rb.empty()
+}
+
+// EnqueueBatch claims the leading free slots with one CAS, see also
+// [ringBuf.EnqueueBatch].
+func (rb *seqRingBuf[T]) EnqueueBatch(items []T) (n int, err error) { //nolint:revive
+	if err = rb.enter(producing); err != nil {
+		return
+	}
+	defer rb.leave(producing)
+
+	if len(items) == 0 {
+		return
+	}
+	if rb.IsClosed() {
+		err = ErrQueueClosed
+		return
+	}
+
+	var pos, k uint64
+	for {
+		pos = atomic.LoadUint64(&rb.enqPos)
+		for k = 0; k < uint64(len(items)) && k < rb.size; k++ {
+			if rb.loadSeq(rb.slot(pos+k)) != pos+k {
+				break
+			}
+		}
+		if k == 0 && atomic.LoadUint64(&rb.enqPos) == pos {
+			err = rb.full()
+			return
+		}
+		if k > 0 && atomic.CompareAndSwapUint64(&rb.enqPos, pos, pos+k) {
+			break
+		}
+		rb.retried()
+	}
+
+	for i := uint64(0); i < k; i++ {
+		holder := rb.slot(pos + i)
+		rb.store(holder, items[i])
+		atomic.StoreUint64(&holder.readWrite, pos+i+1)
+	}
+	rb.produced(k, pos+k)
+
+	if n = int(k); n < len(items) {
+		err = rb.full()
+	}
+	return
+}
+
+// DequeueBatch claims the leading published slots with one CAS, see
+// also [ringBuf.DequeueBatch].
+func (rb *seqRingBuf[T]) DequeueBatch(dst []T) (n int, err error) { //nolint:revive
+	if err = rb.enter(consuming); err != nil {
+		return
+	}
+	defer rb.leave(consuming)
+
+	if len(dst) == 0 {
+		return
+	}
+
+	var pos, k uint64
+	for {
+		pos = atomic.LoadUint64(&rb.deqPos)
+		for k = 0; k < uint64(len(dst)) && k < rb.size; k++ {
+			if rb.loadSeq(rb.slot(pos+k)) != pos+k+1 {
+				break
+			}
+		}
+		if k == 0 && atomic.LoadUint64(&rb.deqPos) == pos {
+			err = rb.errEmpty()
+			return
+		}
+		if k > 0 && atomic.CompareAndSwapUint64(&rb.deqPos, pos, pos+k) {
+			break
+		}
+		rb.retried()
+	}
+
+	for i := uint64(0); i < k; i++ {
+		holder := rb.slot(pos + i)
+		rb.await(holder, pos+i+1)
+		dst[i] = rb.load(holder)
+		atomic.StoreUint64(&holder.readWrite, pos+i+rb.size)
+	}
+	rb.consumed(k)
+	n = int(k)
+	return
+}
+
+func (rb *seqRingBuf[T]) Cap() uint32     { return rb.cap }
+func (rb *seqRingBuf[T]) CapReal() uint32 { return rb.cap }
+
+func (rb *seqRingBuf[T]) Quantity() uint32 { return rb.Size() }
+
+// Size returns the quantity of elements, including the in-flight
+// ones. It's always in the range [0, Cap()], and consistent when
+// uncontended, see [ringBuf.Size].
+func (rb *seqRingBuf[T]) Size() uint32 { return uint32(min(rb.Size64(), uint64(MaxUint32))) }
+
+func (rb *seqRingBuf[T]) Cap64() uint64 { return rb.size }
+
+// Size64 is the 64-bit version of Size, which never overflows.
+//
+// The enqueue position is read again after the dequeue position, so
+// the pair held at the moment the latter was read. Under heavy
+// contention it gives up after a few attempts, and the enqueue
+// position read last is never behind the dequeue position, so the
+// result is not a snapshot, but still in [0, Cap64()].
+func (rb *seqRingBuf[T]) Size64() uint64 {
+	var enq, deq uint64
+	for i := 0; i < consistentReads; i++ {
+		enq = atomic.LoadUint64(&rb.enqPos) &^ sealedPos
+		deq = atomic.LoadUint64(&rb.deqPos)
+		if atomic.LoadUint64(&rb.enqPos)&^sealedPos == enq && enq >= deq {
+			return min(enq-deq, rb.size)
+		}
+	}
+	if enq = atomic.LoadUint64(&rb.enqPos) &^ sealedPos; enq <= deq {
+		return 0 // being reset
+	}
+	return min(enq-deq, rb.size)
+}
+
+// Enqueued returns the total number of elements enqueued since the
+// creation or the last Reset, including the in-flight ones.
+func (rb *seqRingBuf[T]) Enqueued() uint64 { return atomic.LoadUint64(&rb.enqPos) }
+
+// Dequeued returns the total number of elements dequeued since the
+// creation or the last Reset, including the in-flight ones.
+func (rb *seqRingBuf[T]) Dequeued() uint64 { return atomic.LoadUint64(&rb.deqPos) }
+
+func (rb *seqRingBuf[T]) IsEmpty() bool { return rb.Size64() == 0 }
+
+func (rb *seqRingBuf[T]) IsFull() bool { return rb.Size64() == rb.size }
+
+// reset clears the ring buffer, the caller must quiesce it first.
+func (rb *seqRingBuf[T]) reset() {
+	var zero T
+	for i := uint64(0); i < rb.size; i++ {
+		it := rb.slot(i)
+		it.value = zero // release the referenced objects
+		atomic.StoreUint64(&it.readWrite, i)
+	}
+	rb.preAlloc()
+	atomic.StoreUint64(&rb.enqPos, 0)
+	atomic.StoreUint64(&rb.deqPos, 0)
+}
+
+func (rb *seqRingBuf[T]) All() iter.Seq[T] { return values(rb.Enumerate()) }
+
+// Enumerate walks through the published elements without consuming
+// them, see also [ringBuf.Enumerate].
+func (rb *seqRingBuf[T]) Enumerate() iter.Seq2[uint32, T] {
+	return func(yield func(uint32, T) bool) {
+		o := rb.observe(false)
+		o.enumerate(yield)
+	}
+}
+
+func (rb *seqRingBuf[T]) PutCtx(ctx context.Context, item T) (err error) { //nolint:revive
+	return rb.EnqueueCtx(ctx, item)
+}
+
+func (rb *seqRingBuf[T]) EnqueueCtx(ctx context.Context, item T) (err error) { //nolint:revive
+	return enqueueCtx[T](ctx, rb, &rb.notFull, rb.waitStrategy, item)
+}
+
+func (rb *seqRingBuf[T]) GetCtx(ctx context.Context) (item T, err error) { return rb.DequeueCtx(ctx) } //nolint:revive
+
+func (rb *seqRingBuf[T]) DequeueCtx(ctx context.Context) (item T, err error) { //nolint:revive
+	return dequeueCtx[T](ctx, rb, &rb.notEmpty, rb.waitStrategy)
+}
+
+func (rb *seqRingBuf[T]) Drain() iter.Seq[T] { return drain[T](rb) }
+
+func (rb *seqRingBuf[T]) CloseAndDrain() []T { return closeAndDrain[T](rb) }
@@ -0,0 +1,190 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSeqRingBuf_OneByOne(t *testing.T) {
+	rb := NewSequenced[int](8)
+	defer rb.Close()
+
+	if rb.Cap() != 8 || rb.CapReal() != 8 {
+		t.Fatalf("expect all slots usable, but cap = %v/%v", rb.Cap(), rb.CapReal())
+	}
+
+	for i := 0; i < 8; i++ {
+		checkerr(t, rb.Enqueue(i))
+	}
+	if err := rb.Enqueue(8); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expect ErrQueueFull but got %v", err)
+	}
+	if !rb.IsFull() || fmt.Sprint(rb) != "[0,1,2,3,4,5,6,7,]/8" {
+		t.Fatalf("unexpected elements: %v", rb)
+	}
+
+	for i := 0; i < 5; i++ {
+		if it, err := rb.Dequeue(); err != nil || it != i {
+			t.Fatalf("expect %v but got %v, err: %v", i, it, err)
+		}
+	}
+
+	// wrap around
+	n, err := rb.EnqueueBatch([]int{8, 9, 10, 11, 12, 13})
+	if !errors.Is(err, ErrQueueFull) || n != 5 {
+		t.Fatalf("expect 5 items put with ErrQueueFull but got %v, err: %v", n, err)
+	}
+	for pos, v := range rb.Enumerate() {
+		if int(pos)+5 != v {
+			t.Fatalf("expect %v at position %v", v, pos)
+		}
+	}
+
+	dst := make([]int, 16)
+	if n, err = rb.DequeueBatch(dst); err != nil || n != 8 {
+		t.Fatalf("expect 8 items taken but got %v, err: %v", n, err)
+	}
+	if fmt.Sprint(dst[:n]) != "[5 6 7 8 9 10 11 12]" {
+		t.Fatalf("unexpected elements: %v", dst[:n])
+	}
+	if _, err = rb.Dequeue(); !errors.Is(err, ErrQueueEmpty) || !rb.IsEmpty() {
+		t.Fatalf("expect ErrQueueEmpty but got %v", err)
+	}
+
+	checkerr(t, rb.Enqueue(13))
+	rb.Reset()
+	if rb.Size() != 0 {
+		t.Fatalf("expect an empty ring buffer after reset, but got %v", rb)
+	}
+	checkerr(t, rb.Enqueue(14))
+	if items := rb.CloseAndDrain(); fmt.Sprint(items) != "[14]" {
+		t.Fatalf("unexpected elements: %v", items)
+	}
+	if _, err = rb.Dequeue(); !errors.Is(err, ErrQueueClosed) {
+		t.Fatalf("expect ErrQueueClosed but got %v", err)
+	}
+}
+
+func TestSeqRingBuf_Concurrent(t *testing.T) {
+	const producers, consumers, cnt = 4, 4, 20000
+
+	for _, rb := range []RingBuffer[int]{NewSequenced[int](8), NewSequenced[int](5, WithExactCapacity[int]())} {
+		t.Run(fmt.Sprint(rb.Cap()), func(t *testing.T) {
+			testSeqRingBufConcurrent(t, rb, producers, consumers, cnt)
+		})
+	}
+}
+
+func testSeqRingBufConcurrent(t *testing.T, rb RingBuffer[int], producers, consumers, cnt int) {
+	defer rb.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var sum, got int64
+	var sizeErr atomic.Value
+	for i := 0; i < producers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 1; j <= cnt; j++ {
+				if err := rb.EnqueueCtx(ctx, j); err != nil {
+					t.Errorf("[PUT] failed: %v", err)
+					return
+				}
+				if sz := rb.Size(); sz > rb.Cap() {
+					sizeErr.Store(sz)
+				}
+			}
+		}()
+	}
+	for i := 0; i < consumers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.AddInt64(&got, 1) <= int64(producers*cnt) {
+				it, err := rb.DequeueCtx(ctx)
+				if err != nil {
+					t.Errorf("[GET] failed: %v", err)
+					return
+				}
+				atomic.AddInt64(&sum, int64(it))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if expect := int64(producers * cnt * (cnt + 1) / 2); sum != expect {
+		t.Fatalf("expect sum %v but got %v", expect, sum)
+	}
+	if sz := sizeErr.Load(); sz != nil {
+		t.Fatalf("size %v out of range", sz)
+	}
+}
+
+// go test ./mpmc -bench 'Sequenced|Parallel' -run=none
+func BenchmarkRingBuf_PipeSequenced(b *testing.B) { pipe(b, NewSequenced[int](1024)) }
+
+func BenchmarkRingBuf_ParallelMPMC(b *testing.B) { parallel(b, New[int](1024)) }
+
+func BenchmarkRingBuf_ParallelSequenced(b *testing.B) { parallel(b, NewSequenced[int](1024)) }
+
+// parallel puts and gets an item in each of the parallel goroutines.
+func parallel(b *testing.B, rb RingBuffer[int]) {
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			for rb.Enqueue(i) != nil {
+				runtime.Gosched()
+			}
+			for {
+				if _, err := rb.Dequeue(); err == nil {
+					break
+				}
+				runtime.Gosched()
+			}
+		}
+	})
+}
+
+func TestExactCapacity(t *testing.T) {
+	for _, create := range []func(capacity uint32, opts ...Opt[int]) RingBuffer[int]{New[int], NewSequenced[int]} {
+		if rb := create(0, WithExactCapacity[int]()); rb != nil {
+			t.Fatalf("expect nil for a zero capacity, but got %v", rb)
+		}
+		rb := create(80, WithExactCapacity[int]())
+		if rb.Cap() != 80 || rb.CapReal() != 80 {
+			t.Fatalf("expect exactly 80 slots, but cap = %v/%v", rb.Cap(), rb.CapReal())
+		}
+
+		for lap := 0; lap < 3; lap++ {
+			for i := 0; i < 80; i++ {
+				checkerr(t, rb.Enqueue(i))
+			}
+			if err := rb.Enqueue(80); !errors.Is(err, ErrQueueFull) || !rb.IsFull() || rb.Size() != 80 {
+				t.Fatalf("expect ErrQueueFull at 80 but got %v, size %v", err, rb.Size())
+			}
+			for i := 0; i < 80; i++ {
+				if it, err := rb.Dequeue(); err != nil || it != i {
+					t.Fatalf("expect %v but got %v, err: %v", i, it, err)
+				}
+			}
+			if !rb.IsEmpty() {
+				t.Fatalf("expect an empty ring buffer but got %v", rb)
+			}
+
+			// an odd step, so the positions do not line up with the slots
+			checkerr(t, rb.Enqueue(-1))
+			if _, err := rb.Dequeue(); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package mpmc
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// TestSize_Concurrent observes the size while the producers and
+// consumers are running, and while the ring buffer is being cleared.
+// It must be within the capacity, and within one element of the
+// enqueued minus the dequeued ones counted around the call.
+func TestSize_Concurrent(t *testing.T) {
+	const count = 5000
+	for _, c := range []struct {
+		name   string
+		create func(capacity uint32, opts ...Opt[int]) RingBuffer[int]
+	}{
+		{"MPMC", New[int]},
+		{"MPSC", NewMPSC[int]},
+		{"Sequenced", NewSequenced[int]},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.create(16)
+			defer rb.Close()
+
+			var done int32
+			var enqueued, dequeued int64
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for i := 1; i <= count; {
+					if rb.Enqueue(i) == nil {
+						atomic.AddInt64(&enqueued, 1)
+						i++
+					} else {
+						runtime.Gosched()
+					}
+				}
+				atomic.StoreInt32(&done, 1)
+			}()
+			go func() {
+				defer wg.Done()
+				for atomic.LoadInt32(&done) == 0 || !rb.IsEmpty() {
+					if _, err := rb.Dequeue(); err == nil {
+						atomic.AddInt64(&dequeued, 1)
+					} else {
+						runtime.Gosched()
+					}
+				}
+			}()
+
+			for n := 0; atomic.LoadInt32(&done) == 0; n++ {
+				e0, d0 := atomic.LoadInt64(&enqueued), atomic.LoadInt64(&dequeued)
+				size := rb.Size()
+				e1, d1 := atomic.LoadInt64(&enqueued), atomic.LoadInt64(&dequeued)
+				// one producer and one consumer, each might be in
+				// flight without being counted yet.
+				if lo, hi := e0-d1-1, e1-d0+1; size > rb.CapReal() || int64(size) < lo || int64(size) > hi {
+					t.Fatalf("expect a size within [%v, %v] and %v but got %v", lo, hi, rb.CapReal(), size)
+				}
+				if n%64 == 0 {
+					atomic.AddInt64(&dequeued, int64(len(rb.Clear())))
+				}
+				runtime.Gosched()
+			}
+			wg.Wait()
+		})
+	}
+}
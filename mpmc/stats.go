@@ -0,0 +1,261 @@
+package mpmc
+
+import (
+	"runtime"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Stats is a snapshot of the metrics of a ring buffer, see
+// [WithMetrics].
+//
+// The counters are zeros unless the metrics are enabled, Size and Cap
+// are always filled. The counters are summed from the shards one by
+// one, so they're not a consistent snapshot of each other under the
+// concurrent operations. The elements removed by Clear or Reset are
+// not counted as dequeued.
+type Stats struct {
+	Enqueued uint64 // the elements put into the ring buffer
+	Dequeued uint64 // the elements taken from the ring buffer
+	// FullRejections counts the enqueue operations rejected with
+	// [ErrQueueFull], including the partially put batches.
+	FullRejections uint64
+	// EmptyRejections counts the dequeue operations rejected with
+	// [ErrQueueEmpty].
+	EmptyRejections uint64
+	CASRetries      uint64 // the head or tail CAS failed and retried
+	Yields          uint64 // the waits in the lock-free retry loops, see [WaitStrategy]
+	Overwrites      uint64 // the head elements overwritten by the overlapped ring buffer
+	HighWater       uint64 // the maximal size observed after an enqueue
+	Size            uint64 // the current size, see [RingBuffer.Size]
+	Cap             uint64 // the real capacity, see [RingBuffer.CapReal]
+	// Residence is zero unless [WithResidence].
+	Residence Residence
+}
+
+// WithMetrics enables collecting the metrics, see [RingBuffer.Stats].
+//
+// The counters are sharded to keep the hot path cheap, an operation
+// costs an atomic add on a shard which is hardly shared with the
+// other goroutines. The high-water mark is taken from the positions
+// which an enqueue has read anyway. Without it, an operation costs a
+// nil check only.
+func WithMetrics[T any]() Opt[T] {
+	return func(buf *ringBuf[T]) {
+		buf.metrics.Store(newMetrics())
+	}
+}
+
+const (
+	statEnqueued = iota
+	statDequeued
+	statFull
+	statEmpty
+	statRetries
+	statYields
+	statOverwrites
+	statCount
+)
+
+// maxShards limits the memory of the counters on the many-core
+// machines.
+const maxShards = 64
+
+// statShard holds a set of counters in its own cache line(s).
+type statShard struct {
+	v [statCount]uint64
+	_ [CacheLinePadSize - statCount*8%CacheLinePadSize]byte
+}
+
+// metrics collects the counters of a ring buffer, sharded by
+// [stackShard].
+type metrics struct {
+	shards    []statShard
+	mask      uintptr
+	highWater uint64
+}
+
+func newMetrics() *metrics {
+	n := roundUpToPower2(uint32(min(max(runtime.GOMAXPROCS(0), 1), maxShards)))
+	return &metrics{shards: make([]statShard, n), mask: uintptr(n - 1)}
+}
+
+// stackShard picks a shard of a sharded counter for the calling
+// goroutine. Go doesn't expose the current P or CPU, so it's picked
+// by the stack address of the goroutine, which changes only if the
+// stack is moved to grow, and spreads the goroutines across the
+// shards.
+func stackShard(mask uintptr) uintptr {
+	var anchor byte
+	h := uint64(uintptr(unsafe.Pointer(&anchor))) >> 11 //nolint:gomnd // a goroutine stack is 2KB at least
+	h *= 0x9e3779b97f4a7c15                             //nolint:gomnd // fibonacci hashing
+	return uintptr(h>>32) & mask
+}
+
+func (m *metrics) shard() *statShard { return &m.shards[stackShard(m.mask)] } //nolint:revive
+
+func (m *metrics) add(c int, n uint64) { atomic.AddUint64(&m.shard().v[c], n) } //nolint:revive
+
+// mark raises the high-water mark to size.
+func (m *metrics) mark(size uint64) {
+	for hw := atomic.LoadUint64(&m.highWater); size > hw; hw = atomic.LoadUint64(&m.highWater) {
+		if atomic.CompareAndSwapUint64(&m.highWater, hw, size) {
+			return
+		}
+	}
+}
+
+// fill sums the shards into s.
+func (m *metrics) fill(s *Stats) {
+	var v [statCount]uint64
+	for i := range m.shards {
+		for c := range v {
+			v[c] += atomic.LoadUint64(&m.shards[i].v[c])
+		}
+	}
+	s.Enqueued, s.Dequeued = v[statEnqueued], v[statDequeued]
+	s.FullRejections, s.EmptyRejections = v[statFull], v[statEmpty]
+	s.CASRetries, s.Yields, s.Overwrites = v[statRetries], v[statYields], v[statOverwrites]
+	s.HighWater = atomic.LoadUint64(&m.highWater)
+}
+
+// count adds n to the counter c if the metrics are enabled.
+func (rb *ringBuf[T]) count(c int, n uint64) {
+	if m := rb.metrics.Load(); m != nil {
+		m.add(c, n)
+	}
+}
+
+// retried counts a failed head or tail CAS.
+func (rb *ringBuf[T]) retried() { rb.count(statRetries, 1) }
+
+// full returns [ErrQueueFull], and counts and logs the rejection.
+func (rb *ringBuf[T]) full() error {
+	rb.count(statFull, 1)
+	rb.event(evFull)
+	return ErrQueueFull
+}
+
+// empty returns [ErrQueueEmpty], and counts the rejection.
+func (rb *ringBuf[T]) empty() error {
+	rb.count(statEmpty, 1)
+	return ErrQueueEmpty
+}
+
+// rejected counts err if it rejects a dequeue as empty.
+func (rb *ringBuf[T]) rejected(err error) error {
+	if err == ErrQueueEmpty { //nolint:errorlint // the sentinel itself
+		rb.count(statEmpty, 1)
+	}
+	return err
+}
+
+// unseen is the size passed to produced by a caller which has not
+// read the head or tail, such as Commit.
+const unseen = ^uint64(0)
+
+// produced counts n elements put, and wakes up the consumers. size is
+// the quantity of elements seen by the caller after putting them, for
+// the high-water mark, or it's [unseen] and read only if needed.
+func (rb *ringBuf[T]) produced(n, size uint64) {
+	if m := rb.metrics.Load(); m != nil {
+		m.add(statEnqueued, n)
+		if size == unseen {
+			size = uint64(rb.Size())
+		}
+		m.mark(size)
+	}
+	rb.notEmpty.signal()
+}
+
+// consumed counts n elements taken, and wakes up the producers.
+func (rb *ringBuf[T]) consumed(n uint64) {
+	rb.count(statDequeued, n)
+	rb.notFull.signal()
+}
+
+// Stats returns the metrics of the ring buffer, see [WithMetrics].
+func (rb *ringBuf[T]) Stats() (s Stats) {
+	if m := rb.metrics.Load(); m != nil {
+		m.fill(&s)
+	}
+	if h := rb.residence.Load(); h != nil {
+		s.Residence = h.summary()
+	}
+	s.Size, s.Cap = uint64(rb.Size()), uint64(rb.CapReal())
+	return
+}
+
+// ResetCounters zeroes the metrics and the residence histogram, if
+// they're enabled.
+func (rb *ringBuf[T]) ResetCounters() {
+	if h := rb.residence.Load(); h != nil {
+		h.reset()
+	}
+	if m := rb.metrics.Load(); m != nil {
+		for i := range m.shards {
+			for c := range m.shards[i].v {
+				atomic.StoreUint64(&m.shards[i].v[c], 0)
+			}
+		}
+		atomic.StoreUint64(&m.highWater, 0)
+	}
+}
+
+// Debug enables or disables the metrics at runtime, and returns
+// whether they were enabled. The counters start from zero once
+// they're enabled again.
+func (rb *ringBuf[T]) Debug(enabled bool) (lastState bool) {
+	var m *metrics
+	if enabled {
+		m = newMetrics()
+	}
+	for {
+		old := rb.metrics.Load()
+		if lastState = old != nil; lastState == enabled || rb.metrics.CompareAndSwap(old, m) {
+			return
+		}
+	}
+}
+
+// GetGetWaits returns the dequeue operations rejected as empty.
+//
+// Deprecated: use [RingBuffer.Stats], it's EmptyRejections.
+func (rb *ringBuf[T]) GetGetWaits() uint64 { return rb.Stats().EmptyRejections }
+
+// GetPutWaits returns the enqueue operations rejected as full.
+//
+// Deprecated: use [RingBuffer.Stats], it's FullRejections.
+func (rb *ringBuf[T]) GetPutWaits() uint64 { return rb.Stats().FullRejections }
+
+// produced counts n elements put before the enqueue position enq,
+// the high-water mark is taken from enq and the dequeue position read
+// after it, which are never more than Cap() apart.
+func (rb *seqRingBuf[T]) produced(n, enq uint64) {
+	if m := rb.metrics.Load(); m != nil {
+		m.add(statEnqueued, n)
+		m.mark(enq - min(enq, atomic.LoadUint64(&rb.deqPos)))
+	}
+	rb.notEmpty.signal()
+}
+
+func (rb *seqRingBuf[T]) Stats() (s Stats) { //nolint:revive
+	if m := rb.metrics.Load(); m != nil {
+		m.fill(&s)
+	}
+	if h := rb.residence.Load(); h != nil {
+		s.Residence = h.summary()
+	}
+	s.Size, s.Cap = rb.Size64(), rb.size
+	return
+}
+
+// Stats returns the metrics of the current segment, which are shared
+// by the segments before and after resizing.
+func (g *growRingBuf[T]) Stats() (s Stats) {
+	s = within(g, (*seqRingBuf[T]).Stats)
+	if h := g.residence; h != nil {
+		s.Residence = h.summary() // even while the elements are being moved
+	}
+	return
+}
@@ -0,0 +1,154 @@
+package mpmc
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	for _, c := range allCreators(1) {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.create(8, WithMetrics[int]())
+			defer rb.Close()
+
+			capacity := uint64(rb.CapReal())
+			for i := uint64(0); i < capacity; i++ {
+				checkerr(t, rb.Enqueue(int(i)))
+			}
+			err := rb.Enqueue(-1)
+			overwrites, fulls := uint64(0), uint64(1)
+			if c.name == "Overlapped" {
+				checkerr(t, err)
+				overwrites, fulls = 1, 0
+			} else if !errors.Is(err, ErrQueueFull) {
+				t.Fatalf("expect ErrQueueFull but got %v", err)
+			}
+			if s := rb.Stats(); s.Enqueued != capacity+overwrites || s.FullRejections != fulls ||
+				s.Overwrites != overwrites || s.HighWater != capacity || s.Size != capacity || s.Cap != capacity {
+				t.Fatalf("unexpected stats after filling: %+v", s)
+			}
+
+			dst := make([]int, capacity)
+			if n, err := rb.DequeueBatch(dst); err != nil || uint64(n) != capacity {
+				t.Fatalf("expect %v taken, but got %v, err: %v", capacity, n, err)
+			}
+			if _, err := rb.Dequeue(); !errors.Is(err, ErrQueueEmpty) {
+				t.Fatalf("expect ErrQueueEmpty but got %v", err)
+			}
+			if s := rb.Stats(); s.Dequeued != capacity || s.EmptyRejections != 1 || s.Size != 0 || s.HighWater != capacity {
+				t.Fatalf("unexpected stats after draining: %+v", s)
+			}
+
+			rb.ResetCounters()
+			if s := rb.Stats(); s.Enqueued != 0 || s.Dequeued != 0 || s.HighWater != 0 {
+				t.Fatalf("expect the counters reset, but got %+v", s)
+			}
+
+			// Commit hasn't read the head and tail, so the size is read for it
+			checkerr(t, rb.Enqueue(1))
+			_, ticket, err := rb.Reserve()
+			checkerr(t, err)
+			rb.Commit(ticket)
+			if s := rb.Stats(); s.Enqueued != 2 || s.HighWater != 2 {
+				t.Fatalf("expect the committed element counted, but got %+v", s)
+			}
+		})
+	}
+}
+
+func TestStats_Disabled(t *testing.T) {
+	rb := New[int](8)
+	defer rb.Close()
+
+	checkerr(t, rb.Enqueue(1))
+	if s := rb.Stats(); s != (Stats{Size: 1, Cap: 7}) {
+		t.Fatalf("expect the size only, but got %+v", s)
+	}
+	if rb.Debug(true) || !rb.Debug(true) {
+		t.Fatal("expect the metrics enabled once")
+	}
+	checkerr(t, rb.Enqueue(2))
+	if s := rb.Stats(); s.Enqueued != 1 || s.HighWater != 2 {
+		t.Fatalf("expect an element counted, but got %+v", s)
+	}
+	if !rb.Debug(false) || rb.Stats().Enqueued != 0 {
+		t.Fatal("expect the metrics disabled")
+	}
+}
+
+func TestStats_Growable(t *testing.T) {
+	rb := NewGrowable[int](2, 64, WithMetrics[int]())
+	defer rb.Close()
+
+	for i := 0; i < 10; i++ {
+		checkerr(t, rb.Enqueue(i))
+	}
+	if _, err := rb.Dequeue(); err != nil {
+		t.Fatal(err)
+	}
+	// the elements moved on resizing are not counted.
+	if s := rb.Stats(); s.Enqueued != 10 || s.Dequeued != 1 || s.EmptyRejections != 0 || s.Size != 9 {
+		t.Fatalf("unexpected stats: %+v", s)
+	}
+}
+
+// TestStats_Concurrent counts the elements put and taken by the
+// concurrent producers and consumers.
+func TestStats_Concurrent(t *testing.T) {
+	const producers, consumers, count = 2, 2, 5000
+	for _, c := range []struct {
+		name   string
+		create func(capacity uint32, opts ...Opt[int]) RingBuffer[int]
+	}{
+		{"MPMC", New[int]},
+		{"MPSC", NewMPSC[int]},
+		{"Sequenced", NewSequenced[int]},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.create(16, WithMetrics[int]())
+			defer rb.Close()
+
+			p, q := producers, consumers
+			if c.name == "MPSC" {
+				q = 1
+			}
+			var taken int64
+			var wg sync.WaitGroup
+			for i := 0; i < p; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for n := 0; n < count; {
+						if rb.Enqueue(n) == nil {
+							n++
+						} else {
+							runtime.Gosched()
+						}
+					}
+				}()
+			}
+			for i := 0; i < q; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for atomic.LoadInt64(&taken) < int64(p*count) {
+						if _, err := rb.Dequeue(); err == nil {
+							atomic.AddInt64(&taken, 1)
+						} else {
+							runtime.Gosched()
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			if s := rb.Stats(); s.Enqueued != uint64(p*count) || s.Dequeued != uint64(p*count) ||
+				s.Size != 0 || s.HighWater == 0 || s.HighWater > s.Cap {
+				t.Fatalf("unexpected stats: %+v", s)
+			}
+		})
+	}
+}
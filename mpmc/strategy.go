@@ -0,0 +1,101 @@
+package mpmc
+
+import (
+	"runtime"
+	"time"
+)
+
+// WaitStrategy decides how a goroutine waits while it cannot make
+// progress, modelled on the LMAX Disruptor wait strategies.
+//
+// It is used in two places:
+//
+//   - the lock-free retry loops of Enqueue/Dequeue, when a slot is
+//     still being touched by another producer or consumer;
+//   - the blocking operations such as [RingBuffer.EnqueueCtx] and
+//     [RingBuffer.DequeueCtx], while the ring buffer is full or
+//     empty. All strategies poll the ring buffer in this case,
+//     except [ParkingWait], which parks the caller until it is
+//     woken up by the opposite side.
+//
+// See [WithWaitStrategy].
+type WaitStrategy interface {
+	// Idle is called on the n-th consecutive failed attempt, n
+	// starts from 1. It should return when the caller is worth to
+	// retry.
+	Idle(n int)
+}
+
+// BusySpinWait retries immediately. It gives the lowest latency
+// but burns a CPU core while waiting, so it is suitable only if
+// the number of busy goroutines is less than the physical cores.
+func BusySpinWait() WaitStrategy { return busySpinWait{} }
+
+// YieldingWait yields the processor to other goroutines between
+// the retries via [runtime.Gosched].
+func YieldingWait() WaitStrategy { return yieldingWait{} }
+
+// BackoffWait sleeps between the retries, starting from min and
+// doubling the duration on each retry, capped at max.
+func BackoffWait(min, max time.Duration) WaitStrategy { //nolint:revive
+	if min <= 0 {
+		min = time.Microsecond
+	}
+	if max < min {
+		max = min
+	}
+	return backoffWait{min: min, max: max}
+}
+
+// SleepingWait sleeps a fixed duration d between the retries.
+func SleepingWait(d time.Duration) WaitStrategy { return sleepingWait{d: d} }
+
+// ParkingWait yields the processor in the lock-free retry loops,
+// and parks the blocked producers and consumers till they are
+// signalled by the opposite side, so it costs no CPU while the
+// ring buffer stays full or empty.
+//
+// It is the default strategy.
+func ParkingWait() WaitStrategy { return parkingWait{} }
+
+type busySpinWait struct{}
+
+func (busySpinWait) Idle(int) {}
+
+type yieldingWait struct{}
+
+func (yieldingWait) Idle(int) { runtime.Gosched() }
+
+type backoffWait struct {
+	min, max time.Duration
+}
+
+func (s backoffWait) Idle(n int) {
+	d := s.max
+	if n < 32 { //nolint:gomnd // avoid overflow
+		if x := s.min << (n - 1); x > 0 && x < d {
+			d = x
+		}
+	}
+	time.Sleep(d)
+}
+
+type sleepingWait struct {
+	d time.Duration
+}
+
+func (s sleepingWait) Idle(int) { time.Sleep(s.d) }
+
+type parkingWait struct{}
+
+func (parkingWait) Idle(int) { runtime.Gosched() }
+
+// parks reports whether the blocked callers should be parked on
+// a notifier rather than polling with ws.
+func parks(ws WaitStrategy) (yes bool) {
+	if ws == nil {
+		return true
+	}
+	_, yes = ws.(parkingWait)
+	return
+}
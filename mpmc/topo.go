@@ -0,0 +1,338 @@
+package mpmc
+
+import (
+	"context"
+	"iter"
+	"sync/atomic"
+)
+
+// NewSPSC returns a ring buffer for exactly one producer goroutine
+// and one consumer goroutine.
+//
+// Both of Enqueue and Dequeue are wait-free: they use plain atomic
+// loads and stores, and cache the opposite index to avoid touching
+// its cache line on each call. The per-slot states are not used, and
+// the counter of the in-flight operations for Reset is per side, so
+// neither of them writes to a cache line of the other one.
+func NewSPSC[T any](capacity uint32, opts ...Opt[T]) (ringBuffer RingBuffer[T]) {
+	return newTopoRingBuffer(capacity, false, false, opts...)
+}
+
+// NewMPSC returns a ring buffer for many producer goroutines and
+// exactly one consumer goroutine.
+//
+// Dequeue is wait-free, and Enqueue takes one CAS on the tail.
+func NewMPSC[T any](capacity uint32, opts ...Opt[T]) (ringBuffer RingBuffer[T]) {
+	return newTopoRingBuffer(capacity, true, false, opts...)
+}
+
+// NewSPMC returns a ring buffer for exactly one producer goroutine
+// and many consumer goroutines.
+//
+// Enqueue is wait-free, and Dequeue takes one CAS on the head.
+func NewSPMC[T any](capacity uint32, opts ...Opt[T]) (ringBuffer RingBuffer[T]) {
+	return newTopoRingBuffer(capacity, false, true, opts...)
+}
+
+func newTopoRingBuffer[T any](capacity uint32, multiProducers, multiConsumers bool, opts ...Opt[T]) (ringBuffer RingBuffer[T]) {
+	return newRingBuffer(func(capacity uint32, opts ...Opt[T]) (ringBuffer RingBuffer[T]) {
+		rb := &topoRingBuf[T]{
+			multiProducers: multiProducers,
+			multiConsumers: multiConsumers,
+		}
+		for _, opt := range opts {
+			opt(&rb.ringBuf)
+		}
+		rb.alloc(roundUpToPower2(capacity))
+		ringBuffer = rb
+		return
+	}, capacity, opts...)
+}
+
+// topoRingBuf is a ring buffer specialized for a fixed topology of
+// producers and consumers.
+//
+// The single side owns its index exclusively, so it can be moved
+// by a plain store rather than a CAS. A slot state is used only if
+// either side has multiple goroutines: 1 means the slot has been
+// published, 3 means it's being read, and 0 means it has been
+// released.
+type topoRingBuf[T any] struct {
+	ringBuf[T]
+	multiProducers bool
+	multiConsumers bool
+	_              [CacheLinePadSize - 2]byte
+	headCache      uint32                     // the last head seen by the single producer
+	_              [CacheLinePadSize - 4]byte //nolint:revive
+	tailCache      uint32                     // the last tail seen by the single consumer
+	_              [CacheLinePadSize - 4]byte //nolint:revive
+}
+
+func (rb *topoRingBuf[T]) Put(item T) (err error) { return rb.Enqueue(item) } //nolint:revive
+
+func (rb *topoRingBuf[T]) Enqueue(item T) (err error) { //nolint:revive
+	_, err = rb.enqueue(item, nil)
+	return
+}
+
+func (rb *topoRingBuf[T]) Get() (item T, err error) { return rb.Dequeue() } //nolint:revive
+
+func (rb *topoRingBuf[T]) Dequeue() (item T, err error) { //nolint:revive
+	var items [1]T
+	if _, err = rb.dequeue(items[:]); err == nil {
+		item = items[0]
+	}
+	return
+}
+
+// EnqueueBatch puts items with one index reservation, see also
+// [ringBuf.EnqueueBatch].
+func (rb *topoRingBuf[T]) EnqueueBatch(items []T) (n int, err error) { //nolint:revive
+	if len(items) == 0 {
+		return
+	}
+	if n, err = rb.enqueue(items[0], items); err == nil && n < len(items) {
+		err = rb.full()
+	}
+	return
+}
+
+// DequeueBatch takes up to len(dst) elements with one index
+// reservation, see also [ringBuf.DequeueBatch].
+func (rb *topoRingBuf[T]) DequeueBatch(dst []T) (n int, err error) { //nolint:revive
+	if len(dst) == 0 {
+		return
+	}
+	return rb.dequeue(dst)
+}
+
+// enqueue puts items, or the single item if items is nil.
+func (rb *topoRingBuf[T]) enqueue(item T, items []T) (n int, err error) {
+	if err = rb.enter(producing); err != nil {
+		return
+	}
+	defer rb.leave(producing)
+
+	want := uint32(1)
+	if items != nil {
+		want = uint32(len(items))
+	}
+
+	var tail, free, size uint32
+	if tail, free, size, err = rb.reserve(want); err != nil {
+		return
+	}
+	for i := uint32(0); i < free; i++ {
+		holder := rb.writable(tail + i)
+		if items != nil {
+			item = items[i]
+		}
+		rb.store(holder, item)
+		rb.published(holder)
+	}
+	rb.publish(tail, free, uint64(size))
+	n = int(free)
+	return
+}
+
+// reserve claims up to want free slots from the tail, size is the
+// quantity of elements seen with them.
+func (rb *topoRingBuf[T]) reserve(want uint32) (tail, free, size uint32, err error) {
+	if rb.IsClosed() {
+		err = ErrQueueClosed
+		return
+	}
+
+	var head uint32
+	if rb.multiProducers {
+		for {
+			tail = atomic.LoadUint32(&rb.tail)
+			head = atomic.LoadUint32(&rb.head)
+			if tail == MaxUint32 {
+				err = rb.notReady()
+				return
+			}
+			if free = rb.capModMask - rb.qty(head, tail); free == 0 {
+				err = rb.full()
+				return
+			}
+			free = min(free, want)
+			if atomic.CompareAndSwapUint32(&rb.tail, tail, (tail+free)&rb.capModMask) {
+				size = rb.qty(head, tail) + free
+				return
+			}
+			rb.retried()
+		}
+	}
+
+	tail = atomic.LoadUint32(&rb.tail)
+	if tail == MaxUint32 {
+		err = rb.notReady()
+		return
+	}
+	if free = rb.capModMask - rb.qty(rb.headCache, tail); free < want {
+		rb.headCache = atomic.LoadUint32(&rb.head)
+		if free = rb.capModMask - rb.qty(rb.headCache, tail); free == 0 {
+			err = rb.full()
+			return
+		}
+	}
+	free = min(free, want)
+	size = rb.qty(rb.headCache, tail) + free
+	return
+}
+
+// writable returns the reserved slot at index, after a slower
+// consumer has done with it.
+func (rb *topoRingBuf[T]) writable(index uint32) (holder *rbItem[T]) {
+	holder = rb.at(index & rb.capModMask)
+	if rb.multiConsumers {
+		for spins := 0; atomic.LoadUint64(&holder.readWrite) != 0; {
+			spins++
+			rb.idle(spins)
+		}
+	}
+	return
+}
+
+// published marks a written slot readable for the multiple sides.
+func (rb *topoRingBuf[T]) published(holder *rbItem[T]) {
+	if rb.multiConsumers || rb.multiProducers {
+		atomic.StoreUint64(&holder.readWrite, 1)
+	}
+}
+
+// publish moves the tail for the single producer, and wakes up the
+// parked consumers, see [ringBuf.produced] for size.
+func (rb *topoRingBuf[T]) publish(tail, n uint32, size uint64) {
+	if !rb.multiProducers {
+		atomic.StoreUint32(&rb.tail, (tail+n)&rb.capModMask)
+	}
+	rb.produced(uint64(n), size)
+}
+
+func (rb *topoRingBuf[T]) dequeue(dst []T) (n int, err error) {
+	if err = rb.enter(consuming); err != nil {
+		return
+	}
+	defer rb.leave(consuming)
+
+	var head, avail uint32
+	if head, avail, err = rb.acquire(uint32(len(dst))); err != nil {
+		return
+	}
+	for i := uint32(0); i < avail; i++ {
+		holder := rb.readable(head + i)
+		dst[i] = rb.load(holder)
+		rb.released(holder)
+	}
+	rb.release(head, avail)
+	n = int(avail)
+	return
+}
+
+// acquire claims up to want published slots from the head.
+func (rb *topoRingBuf[T]) acquire(want uint32) (head, avail uint32, err error) {
+	var tail uint32
+	if rb.multiConsumers {
+		for {
+			head = atomic.LoadUint32(&rb.head)
+			tail = atomic.LoadUint32(&rb.tail)
+			if head == tail {
+				err = rb.rejected(rb.errEmpty(head))
+				return
+			}
+			avail = min(rb.qty(head, tail), want)
+			if atomic.CompareAndSwapUint32(&rb.head, head, (head+avail)&rb.capModMask) {
+				return
+			}
+			rb.retried()
+		}
+	}
+
+	head = atomic.LoadUint32(&rb.head)
+	if avail = rb.qty(head, rb.tailCache); avail < want || head == MaxUint32 {
+		rb.tailCache = atomic.LoadUint32(&rb.tail)
+		if head == rb.tailCache {
+			err = rb.rejected(rb.errEmpty(head))
+			return
+		}
+		avail = rb.qty(head, rb.tailCache)
+	}
+	avail = min(avail, want)
+	return
+}
+
+// readable returns the acquired slot at index, after a slower
+// producer has published it, and a peeker has done with it.
+func (rb *topoRingBuf[T]) readable(index uint32) (holder *rbItem[T]) {
+	holder = rb.at(index & rb.capModMask)
+	if rb.multiProducers || rb.multiConsumers {
+		rb.claim(holder, 1, 3) //nolint:gomnd
+	}
+	return
+}
+
+// released marks a read slot writable for the multiple sides.
+func (rb *topoRingBuf[T]) released(holder *rbItem[T]) {
+	if rb.multiProducers || rb.multiConsumers {
+		atomic.StoreUint64(&holder.readWrite, 0)
+	}
+}
+
+// release moves the head for the single consumer, and wakes up the
+// parked producers.
+func (rb *topoRingBuf[T]) release(head, n uint32) {
+	if !rb.multiConsumers {
+		atomic.StoreUint32(&rb.head, (head+n)&rb.capModMask)
+	}
+	rb.consumed(uint64(n))
+}
+
+func (rb *topoRingBuf[T]) errEmpty(head uint32) error {
+	if head == MaxUint32 {
+		return rb.notReady()
+	}
+	if rb.IsClosed() {
+		return ErrQueueClosed
+	}
+	return ErrQueueEmpty
+}
+
+// reset clears the ring buffer, the caller must quiesce it first.
+func (rb *topoRingBuf[T]) reset() {
+	rb.ringBuf.reset()
+	rb.headCache, rb.tailCache = 0, 0
+}
+
+func (rb *topoRingBuf[T]) PutCtx(ctx context.Context, item T) (err error) { //nolint:revive
+	return rb.EnqueueCtx(ctx, item)
+}
+
+func (rb *topoRingBuf[T]) EnqueueCtx(ctx context.Context, item T) (err error) { //nolint:revive
+	return enqueueCtx[T](ctx, rb, &rb.notFull, rb.waitStrategy, item)
+}
+
+func (rb *topoRingBuf[T]) GetCtx(ctx context.Context) (item T, err error) { return rb.DequeueCtx(ctx) } //nolint:revive
+
+func (rb *topoRingBuf[T]) DequeueCtx(ctx context.Context) (item T, err error) { //nolint:revive
+	return dequeueCtx[T](ctx, rb, &rb.notEmpty, rb.waitStrategy)
+}
+
+func (rb *topoRingBuf[T]) All() iter.Seq[T] { return values(rb.Enumerate()) }
+
+// Enumerate walks through the elements without consuming them, see
+// also [ringBuf.Enumerate].
+//
+// In SPSC mode there are no slot states to hold the slots, so like
+// [topoRingBuf.Snapshot], it must be called by the consumer.
+func (rb *topoRingBuf[T]) Enumerate() iter.Seq2[uint32, T] {
+	return func(yield func(uint32, T) bool) {
+		o := rb.observe(false)
+		o.enumerate(yield)
+	}
+}
+
+func (rb *topoRingBuf[T]) Drain() iter.Seq[T] { return drain[T](rb) }
+
+func (rb *topoRingBuf[T]) CloseAndDrain() []T { return closeAndDrain[T](rb) }
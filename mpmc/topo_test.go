@@ -0,0 +1,186 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type creator struct {
+	name      string
+	create    func(capacity uint32, opts ...Opt[int]) RingBuffer[int]
+	producers int
+	consumers int
+}
+
+var topologies = []creator{
+	{"MPMC", New[int], 4, 4},
+	{"SPSC", NewSPSC[int], 1, 1},
+	{"MPSC", NewMPSC[int], 4, 1},
+	{"SPMC", NewSPMC[int], 1, 4},
+}
+
+// allCreators returns the topologies and the other ring buffers of
+// the package. The growable one may grow to growth times of its
+// initial capacity.
+func allCreators(growth uint32) []creator {
+	return append(topologies[:len(topologies):len(topologies)],
+		creator{"Sequenced", NewSequenced[int], 4, 4},
+		creator{"Overlapped", func(capacity uint32, opts ...Opt[int]) RingBuffer[int] {
+			return NewOverlappedRingBuffer(capacity, opts...)
+		}, 4, 4},
+		creator{"Growable", func(capacity uint32, opts ...Opt[int]) RingBuffer[int] {
+			return NewGrowable(capacity, capacity*growth, opts...)
+		}, 4, 4},
+	)
+}
+
+func TestTopoRingBuf_OneByOne(t *testing.T) {
+	for _, c := range topologies {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.create(8)
+			defer rb.Close()
+
+			for i := uint32(0); i < rb.CapReal(); i++ {
+				checkerr(t, rb.Enqueue(int(i)))
+			}
+			if err := rb.Enqueue(-1); !errors.Is(err, ErrQueueFull) {
+				t.Fatalf("expect ErrQueueFull but got %v", err)
+			}
+			if fmt.Sprint(rb) != "[0,1,2,3,4,5,6,]/7" {
+				t.Fatalf("unexpected elements: %v", rb)
+			}
+
+			var got []int
+			for v := range rb.All() {
+				got = append(got, v)
+			}
+			if fmt.Sprint(got) != "[0 1 2 3 4 5 6]" {
+				t.Fatalf("unexpected elements: %v", got)
+			}
+
+			for i := 0; i < 4; i++ {
+				it, err := rb.Dequeue()
+				if err != nil || it != i {
+					t.Fatalf("expect %v but got %v, err: %v", i, it, err)
+				}
+			}
+
+			// wrap around
+			n, err := rb.EnqueueBatch([]int{7, 8, 9, 10, 11})
+			if !errors.Is(err, ErrQueueFull) || n != 4 {
+				t.Fatalf("expect 4 items put with ErrQueueFull but got %v, err: %v", n, err)
+			}
+			dst := make([]int, 10)
+			if n, err = rb.DequeueBatch(dst); err != nil || n != 7 {
+				t.Fatalf("expect 7 items taken but got %v, err: %v", n, err)
+			}
+			if fmt.Sprint(dst[:n]) != "[4 5 6 7 8 9 10]" {
+				t.Fatalf("unexpected elements: %v", dst[:n])
+			}
+			if _, err = rb.Dequeue(); !errors.Is(err, ErrQueueEmpty) {
+				t.Fatalf("expect ErrQueueEmpty but got %v", err)
+			}
+
+			rb.Reset()
+			checkerr(t, rb.Enqueue(12))
+			if items := rb.CloseAndDrain(); fmt.Sprint(items) != "[12]" {
+				t.Fatalf("unexpected elements: %v", items)
+			}
+			if _, err = rb.Dequeue(); !errors.Is(err, ErrQueueClosed) {
+				t.Fatalf("expect ErrQueueClosed but got %v", err)
+			}
+		})
+	}
+}
+
+func TestTopoRingBuf_Concurrent(t *testing.T) {
+	const cnt = 20000
+	for _, c := range topologies {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.create(16)
+			defer rb.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			var wg sync.WaitGroup
+			var sum, got int64
+			for i := 0; i < c.producers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 1; j <= cnt; j++ {
+						if err := rb.EnqueueCtx(ctx, j); err != nil {
+							t.Errorf("[PUT] failed: %v", err)
+							return
+						}
+					}
+				}()
+			}
+			for i := 0; i < c.consumers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					last := 0
+					for atomic.AddInt64(&got, 1) <= int64(c.producers*cnt) {
+						it, err := rb.DequeueCtx(ctx)
+						if err != nil {
+							t.Errorf("[GET] failed: %v", err)
+							return
+						}
+						if c.producers == 1 && c.consumers == 1 && it != last+1 {
+							t.Errorf("[GET] expect %v but got %v", last+1, it)
+							return
+						}
+						last = it
+						atomic.AddInt64(&sum, int64(it))
+					}
+				}()
+			}
+			wg.Wait()
+
+			if expect := int64(c.producers * cnt * (cnt + 1) / 2); sum != expect {
+				t.Fatalf("expect sum %v but got %v", expect, sum)
+			}
+		})
+	}
+}
+
+// go test ./mpmc -bench 'BenchmarkRingBuf_Pipe' -run=none
+func BenchmarkRingBuf_PipeMPMC(b *testing.B) { pipe(b, New[int](1024)) }
+
+func BenchmarkRingBuf_PipeSPSC(b *testing.B) { pipe(b, NewSPSC[int](1024)) }
+
+func BenchmarkRingBuf_PipeMPSC(b *testing.B) { pipe(b, NewMPSC[int](1024)) }
+
+func BenchmarkRingBuf_PipeSPMC(b *testing.B) { pipe(b, NewSPMC[int](1024)) }
+
+// pipe moves b.N items from one producer to one consumer.
+func pipe(b *testing.B, rb RingBuffer[int]) {
+	done := make(chan struct{})
+	b.ResetTimer()
+	go func() {
+		defer close(done)
+		for i := 0; i < b.N; {
+			if _, err := rb.Dequeue(); err != nil {
+				runtime.Gosched()
+				continue
+			}
+			i++
+		}
+	}()
+	for i := 0; i < b.N; {
+		if err := rb.Enqueue(i); err != nil {
+			runtime.Gosched()
+			continue
+		}
+		i++
+	}
+	<-done
+}
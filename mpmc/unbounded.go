@@ -0,0 +1,221 @@
+package mpmc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// NewUnbounded returns an MPMC queue without a hard capacity, which
+// chains the sequenced ring buffers of segmentSize slots, see
+// [NewSequenced]. Enqueue never returns [ErrQueueFull].
+//
+// A full segment is sealed and followed by a new one, and a drained
+// segment is recycled for the next use, so the steady state does not
+// allocate. The options are applied to each segment.
+func NewUnbounded[T any](segmentSize uint32, opts ...Opt[T]) (queue Queue[T]) {
+	if !isInitialized() {
+		return
+	}
+	q := &unbounded[T]{segmentSize: segmentSize, opts: opts}
+	var err error
+	if q.free, err = newSeqRingBuf[*chainSeg[T]](unboundedFreeSegments); err != nil {
+		return
+	}
+	s, err := q.alloc()
+	if err != nil {
+		return
+	}
+	q.head.Store(s)
+	q.tail.Store(s)
+	queue = q
+	return
+}
+
+// unboundedFreeSegments is the number of drained segments kept for
+// recycling, the extra ones are left to GC.
+const unboundedFreeSegments = 4
+
+// sealedPos is set in the enqPos of a sealed segment. The producers
+// cannot claim any position after that, they check it before the
+// sequence number of the slot, which could be of the last lap and be
+// taken as a position claimed by another producer.
+const sealedPos = uint64(1) << 63
+
+// chainSeg is a segment of the unbounded queue. refs counts the
+// goroutines which are working on it, a segment is recycled only if
+// nobody is working on it.
+type chainSeg[T any] struct {
+	rb   *seqRingBuf[T]
+	next atomic.Pointer[chainSeg[T]]
+	refs int32
+}
+
+// seal stops the producers from claiming the positions of the segment.
+func (s *chainSeg[T]) seal() {
+	for {
+		pos := atomic.LoadUint64(&s.rb.enqPos)
+		if pos&sealedPos != 0 || atomic.CompareAndSwapUint64(&s.rb.enqPos, pos, pos|sealedPos) {
+			return
+		}
+	}
+}
+
+// drained reports whether all of the positions claimed by the
+// producers of a sealed segment have been claimed by the consumers.
+func (s *chainSeg[T]) drained() bool {
+	enq := atomic.LoadUint64(&s.rb.enqPos)
+	return enq&sealedPos != 0 && atomic.LoadUint64(&s.rb.deqPos) == enq&^sealedPos
+}
+
+func (s *chainSeg[T]) size() uint64 { return s.rb.Size64() }
+
+// unbounded is a linked chain of segments, the producers work on the
+// tail segment and the consumers work on the head segment.
+type unbounded[T any] struct {
+	head        atomic.Pointer[chainSeg[T]]
+	_           [CacheLinePadSize - 8]byte
+	tail        atomic.Pointer[chainSeg[T]]
+	_           [CacheLinePadSize - 8]byte //nolint:revive
+	free        *seqRingBuf[*chainSeg[T]]
+	segmentSize uint32
+	opts        []Opt[T]
+}
+
+// enter registers the caller as a user of the segment at end, which is
+// the head or the tail.
+func (q *unbounded[T]) enter(end *atomic.Pointer[chainSeg[T]]) (s *chainSeg[T]) {
+	for {
+		s = end.Load()
+		atomic.AddInt32(&s.refs, 1)
+		if end.Load() == s {
+			return
+		}
+		atomic.AddInt32(&s.refs, -1)
+	}
+}
+
+func (q *unbounded[T]) leave(s *chainSeg[T]) { atomic.AddInt32(&s.refs, -1) }
+
+func (q *unbounded[T]) alloc() (s *chainSeg[T], err error) {
+	if s, err = q.free.Dequeue(); err == nil {
+		return
+	}
+	var rb *seqRingBuf[T]
+	if rb, err = newSeqRingBuf(uint64(q.segmentSize), q.opts...); err == nil {
+		s = &chainSeg[T]{rb: rb}
+	}
+	return
+}
+
+// recycle puts an unlinked segment back to the free list, if nobody
+// is working on it.
+func (q *unbounded[T]) recycle(s *chainSeg[T]) {
+	if atomic.LoadInt32(&s.refs) != 0 {
+		return // leave it to GC
+	}
+	s.rb.Reset()
+	s.next.Store(nil)
+	_ = q.free.Enqueue(s)
+}
+
+func (q *unbounded[T]) Enqueue(item T) (err error) { //nolint:revive
+	for {
+		s := q.enter(&q.tail)
+		if err = s.rb.Enqueue(item); !errors.Is(err, ErrQueueFull) {
+			q.leave(s)
+			return
+		}
+
+		// the segment is full, seal it and move on to the next one.
+		s.seal()
+		next := s.next.Load()
+		if next == nil {
+			var n *chainSeg[T]
+			if n, err = q.alloc(); err != nil {
+				q.leave(s)
+				return
+			}
+			if s.next.CompareAndSwap(nil, n) {
+				next = n
+			} else {
+				next = s.next.Load()
+				q.recycle(n)
+			}
+		}
+		q.tail.CompareAndSwap(s, next)
+		q.leave(s)
+	}
+}
+
+func (q *unbounded[T]) Dequeue() (item T, err error) { //nolint:revive
+	for {
+		s := q.enter(&q.head)
+		if item, err = s.rb.Dequeue(); !errors.Is(err, ErrQueueEmpty) || !s.drained() {
+			q.leave(s)
+			return
+		}
+
+		// the sealed segment has been drained, move on to the next one.
+		next := s.next.Load()
+		if next == nil {
+			q.leave(s)
+			return // the producer is linking the next one
+		}
+		q.tail.CompareAndSwap(s, next) // tail must not stay on an unlinked segment
+		moved := q.head.CompareAndSwap(s, next)
+		q.leave(s)
+		if moved {
+			q.recycle(s)
+		}
+	}
+}
+
+// Cap returns MaxUint32 since the queue is unbounded.
+func (q *unbounded[T]) Cap() uint32 { return MaxUint32 }
+
+// CapReal returns MaxUint32 since the queue is unbounded.
+func (q *unbounded[T]) CapReal() uint32 { return MaxUint32 }
+
+// Size returns the quantity of elements by walking through the
+// segments, it's a snapshot while the producers or consumers are
+// running.
+func (q *unbounded[T]) Size() uint32 {
+	var n uint64
+	for s := q.head.Load(); s != nil && n < uint64(MaxUint32); s = s.next.Load() {
+		n += s.size()
+	}
+	return uint32(min(n, uint64(MaxUint32)))
+}
+
+func (q *unbounded[T]) IsEmpty() bool { return q.Size() == 0 }
+
+// IsFull always returns false since the queue is unbounded.
+func (q *unbounded[T]) IsFull() bool { return false }
+
+// Reset clears the queue, it's unsafe while any producer or consumer
+// is running.
+func (q *unbounded[T]) Reset() {
+	s := q.head.Load()
+	for n := s.next.Load(); n != nil; {
+		next := n.next.Load()
+		q.recycle(n)
+		n = next
+	}
+	s.rb.Reset()
+	s.next.Store(nil)
+	q.tail.Store(s)
+}
+
+func (q *unbounded[T]) String() string {
+	var sb strings.Builder
+	_, _ = sb.WriteRune('[')
+	for s := q.head.Load(); s != nil; s = s.next.Load() {
+		for _, v := range s.rb.Snapshot() {
+			_, _ = sb.WriteString(fmt.Sprintf("%v,", v))
+		}
+	}
+	_, _ = sb.WriteString(fmt.Sprintf("]/%v", q.Size()))
+	return sb.String()
+}
@@ -0,0 +1,162 @@
+package mpmc
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestUnbounded_OneByOne(t *testing.T) {
+	q := NewUnbounded[int](4)
+	if !q.IsEmpty() || q.IsFull() || q.Cap() != MaxUint32 {
+		t.Fatalf("expect an empty unbounded queue, but got %v", q)
+	}
+
+	for lap := 0; lap < 3; lap++ {
+		for i := 0; i < 10; i++ {
+			checkerr(t, q.Enqueue(i))
+		}
+		if q.Size() != 10 || fmt.Sprint(q) != "[0,1,2,3,4,5,6,7,8,9,]/10" {
+			t.Fatalf("unexpected elements: %v", q)
+		}
+		for i := 0; i < 10; i++ {
+			if it, err := q.Dequeue(); err != nil || it != i {
+				t.Fatalf("expect %v but got %v, err: %v", i, it, err)
+			}
+		}
+		if _, err := q.Dequeue(); !errors.Is(err, ErrQueueEmpty) || !q.IsEmpty() {
+			t.Fatalf("expect ErrQueueEmpty but got %v", err)
+		}
+	}
+
+	// the drained segments are recycled
+	u := q.(*unbounded[int])
+	if u.free.Size() == 0 {
+		t.Fatal("expect the drained segments recycled")
+	}
+	allocs := testing.AllocsPerRun(10, func() {
+		for i := 0; i < 10; i++ {
+			_ = q.Enqueue(i)
+		}
+		for i := 0; i < 10; i++ {
+			_, _ = q.Dequeue()
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("expect no allocations in the steady state, but got %v", allocs)
+	}
+
+	for i := 0; i < 10; i++ {
+		checkerr(t, q.Enqueue(i))
+	}
+	q.Reset()
+	if !q.IsEmpty() || u.head.Load() != u.tail.Load() {
+		t.Fatalf("expect an empty queue after reset, but got %v", q)
+	}
+}
+
+func TestUnbounded_Concurrent(t *testing.T) {
+	const producers, consumers, cnt = 4, 4, 20000
+
+	q := NewUnbounded[int](16)
+
+	var wg sync.WaitGroup
+	var sum, got int64
+	for i := 0; i < producers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 1; j <= cnt; j++ {
+				if err := q.Enqueue(j); err != nil {
+					t.Errorf("[PUT] failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < consumers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.AddInt64(&got, 1) <= producers*cnt {
+				for {
+					it, err := q.Dequeue()
+					if err == nil {
+						atomic.AddInt64(&sum, int64(it))
+						break
+					}
+					if !errors.Is(err, ErrQueueEmpty) {
+						t.Errorf("[GET] failed: %v", err)
+						return
+					}
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if expect := int64(producers * cnt * (cnt + 1) / 2); sum != expect {
+		t.Fatalf("expect sum %v but got %v", expect, sum)
+	}
+	if !q.IsEmpty() {
+		t.Fatalf("expect an empty queue, but got %v", q.Size())
+	}
+}
+
+// TestUnbounded_Producers enqueues with no consumer, the sealed
+// segments stay full and the producers must move on rather than spin.
+func TestUnbounded_Producers(t *testing.T) {
+	const producers, cnt = 8, 5000
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	q := NewUnbounded[int](4)
+
+	var wg sync.WaitGroup
+	for i := 0; i < producers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < cnt; j++ {
+				if err := q.Enqueue(j); err != nil {
+					t.Errorf("[PUT] failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := q.Size(); n != producers*cnt {
+		t.Fatalf("expect %v elements but got %v", producers*cnt, n)
+	}
+}
+
+func TestUnbounded_Order(t *testing.T) {
+	const cnt = 100000
+
+	q := NewUnbounded[int](8)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < cnt; {
+			it, err := q.Dequeue()
+			if err != nil {
+				runtime.Gosched()
+				continue
+			}
+			if it != i {
+				t.Errorf("expect %v but got %v", i, it)
+				return
+			}
+			i++
+		}
+	}()
+	for i := 0; i < cnt; i++ {
+		checkerr(t, q.Enqueue(i))
+	}
+	<-done
+}
@@ -0,0 +1,166 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// notifier parks the goroutines which are waiting for the opposite
+// side of a ring buffer to make progress.
+//
+// The zero value is ready to use.
+type notifier struct {
+	waiters int32
+	mu      sync.Mutex
+	ch      chan struct{}
+}
+
+// signal wakes up all parked waiters.
+//
+// It costs one atomic load only if nobody is waiting, so it is cheap
+// enough to be called in the non-blocking fast path.
+func (n *notifier) signal() {
+	if atomic.LoadInt32(&n.waiters) != 0 {
+		n.broadcast()
+	}
+}
+
+func (n *notifier) broadcast() {
+	n.mu.Lock()
+	if n.ch != nil {
+		close(n.ch)
+		n.ch = nil
+	}
+	n.mu.Unlock()
+}
+
+// arm registers the caller as a waiter and returns a channel which
+// will be closed by the next signal. The caller must invoke disarm
+// once it stops waiting.
+func (n *notifier) arm() <-chan struct{} {
+	atomic.AddInt32(&n.waiters, 1)
+	n.mu.Lock()
+	if n.ch == nil {
+		n.ch = make(chan struct{})
+	}
+	ch := n.ch
+	n.mu.Unlock()
+	return ch
+}
+
+func (n *notifier) disarm() { atomic.AddInt32(&n.waiters, -1) }
+
+type enqueuer[T any] interface {
+	Enqueue(item T) (err error)
+}
+
+type dequeuer[T any] interface {
+	Dequeue() (item T, err error)
+}
+
+// enqueueCtx tries q.Enqueue and waits while the queue is full,
+// until a slot is freed or ctx is done.
+func enqueueCtx[T any](ctx context.Context, q enqueuer[T], n *notifier, ws WaitStrategy, item T) (err error) {
+	full := func() bool {
+		err = q.Enqueue(item)
+		return errors.Is(err, ErrQueueFull)
+	}
+	if full() {
+		if e := await(ctx, n, ws, full); e != nil {
+			err = e
+		}
+	}
+	return
+}
+
+// dequeueCtx tries q.Dequeue and waits while the queue is empty,
+// until an element arrives or ctx is done.
+func dequeueCtx[T any](ctx context.Context, q dequeuer[T], n *notifier, ws WaitStrategy) (item T, err error) {
+	empty := func() bool {
+		item, err = q.Dequeue()
+		return errors.Is(err, ErrQueueEmpty)
+	}
+	if empty() {
+		if e := await(ctx, n, ws, empty); e != nil {
+			err = e
+		}
+	}
+	return
+}
+
+// await retries blocked until it returns false, or ctx is done.
+//
+// The caller will be parked on n between the retries if ws is a
+// parking strategy, or else ws.Idle is used.
+func await(ctx context.Context, n *notifier, ws WaitStrategy, blocked func() bool) error {
+	if !parks(ws) {
+		for i := 1; blocked(); i++ {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+			ws.Idle(i)
+		}
+		return nil
+	}
+
+	for {
+		ch := n.arm()
+		// retry once after armed, the opposite side might make
+		// progress before we were registered as a waiter.
+		if !blocked() {
+			n.disarm()
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			n.disarm()
+			return ctx.Err()
+		case <-ch:
+			n.disarm()
+		}
+	}
+}
+
+func (rb *ringBuf[T]) PutCtx(ctx context.Context, item T) (err error) { //nolint:revive
+	return rb.EnqueueCtx(ctx, item)
+}
+
+// EnqueueCtx puts item into the ring buffer. If the ring buffer is
+// full, it blocks until a slot is freed by a consumer or ctx is
+// done, and ctx.Err() will be returned in the latter case.
+func (rb *ringBuf[T]) EnqueueCtx(ctx context.Context, item T) (err error) { //nolint:revive
+	return enqueueCtx[T](ctx, rb, &rb.notFull, rb.waitStrategy, item)
+}
+
+func (rb *ringBuf[T]) GetCtx(ctx context.Context) (item T, err error) { return rb.DequeueCtx(ctx) } //nolint:revive
+
+// DequeueCtx takes an element from the ring buffer. If the ring
+// buffer is empty, it blocks until a producer puts something or
+// ctx is done, and ctx.Err() will be returned in the latter case.
+func (rb *ringBuf[T]) DequeueCtx(ctx context.Context) (item T, err error) { //nolint:revive
+	return dequeueCtx[T](ctx, rb, &rb.notEmpty, rb.waitStrategy)
+}
+
+func (rb *orbuf[T]) PutCtx(ctx context.Context, item T) (err error) { //nolint:revive
+	return rb.EnqueueCtx(ctx, item)
+}
+
+// EnqueueCtx puts item into the overlapped ring buffer. It never
+// blocks since the head element will be overwritten if the ring
+// buffer is full.
+func (rb *orbuf[T]) EnqueueCtx(ctx context.Context, item T) (err error) { //nolint:revive
+	return enqueueCtx[T](ctx, rb, &rb.notFull, rb.waitStrategy, item)
+}
+
+func (rb *orbuf[T]) GetCtx(ctx context.Context) (item T, err error) { return rb.DequeueCtx(ctx) } //nolint:revive
+
+// DequeueCtx takes an element from the ring buffer, or blocks until
+// a producer puts something or ctx is done.
+func (rb *orbuf[T]) DequeueCtx(ctx context.Context) (item T, err error) { //nolint:revive
+	return dequeueCtx[T](ctx, rb, &rb.notEmpty, rb.waitStrategy)
+}
@@ -0,0 +1,189 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRingBuf_DequeueCtx(t *testing.T) {
+	rb := New[int](NLtd)
+	defer rb.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := rb.DequeueCtx(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expect DeadlineExceeded but got %v", err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = rb.Put(7)
+	}()
+
+	it, err := rb.GetCtx(context.Background())
+	if err != nil || it != 7 {
+		t.Fatalf("expect 7 but got %v, err: %v", it, err)
+	}
+}
+
+func TestRingBuf_EnqueueCtx(t *testing.T) {
+	rb := New[int](4)
+	defer rb.Close()
+
+	for i := uint32(0); i < rb.CapReal(); i++ {
+		checkerr(t, rb.Enqueue(int(i)))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := rb.EnqueueCtx(ctx, 9); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expect DeadlineExceeded but got %v", err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = rb.Get()
+	}()
+
+	checkerr(t, rb.PutCtx(context.Background(), 9))
+	if sz := rb.Size(); sz != rb.CapReal() {
+		t.Fatalf("expect size %v but got %v", rb.CapReal(), sz)
+	}
+}
+
+func TestOverlappedRingBuf_Ctx(t *testing.T) {
+	rb := NewOverlappedRingBuffer[int](4)
+	defer rb.Close()
+
+	// never blocks on a full overlapped ring buffer
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; i < 10; i++ {
+		checkerr(t, rb.EnqueueCtx(ctx, i))
+	}
+
+	for i := 7; i < 10; i++ {
+		it, err := rb.DequeueCtx(ctx)
+		checkerr(t, err)
+		if it != i {
+			t.Fatalf("expect %v but got %v", i, it)
+		}
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = rb.Put(11)
+	}()
+	if it, err := rb.GetCtx(ctx); err != nil || it != 11 {
+		t.Fatalf("expect 11 but got %v, err: %v", it, err)
+	}
+}
+
+func TestRingBuf_CtxMPMC(t *testing.T) {
+	const producers, consumers, cnt = 4, 4, 10000
+
+	rb := New[int](8)
+	defer rb.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var sum, got int64
+	for i := 0; i < producers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 1; j <= cnt; j++ {
+				if err := rb.EnqueueCtx(ctx, j); err != nil {
+					t.Errorf("[PUT] failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < consumers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.AddInt64(&got, 1) <= producers*cnt {
+				it, err := rb.DequeueCtx(ctx)
+				if err != nil {
+					t.Errorf("[GET] failed: %v", err)
+					return
+				}
+				atomic.AddInt64(&sum, int64(it))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if expect := int64(producers * cnt * (cnt + 1) / 2); sum != expect {
+		t.Fatalf("expect sum %v but got %v", expect, sum)
+	}
+}
+
+func TestRingBuf_WaitStrategies(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		ws   WaitStrategy
+	}{
+		{"busy-spin", BusySpinWait()},
+		{"yielding", YieldingWait()},
+		{"backoff", BackoffWait(time.Microsecond, time.Millisecond)},
+		{"sleeping", SleepingWait(100 * time.Microsecond)},
+		{"parking", ParkingWait()},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if _, spin := c.ws.(busySpinWait); spin && runtime.NumCPU() < 2 {
+				t.Skip("busy-spin needs more than one core to make progress")
+			}
+
+			rb := New(4, WithWaitStrategy[int](c.ws))
+			defer rb.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			if _, err := rb.DequeueCtx(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expect DeadlineExceeded but got %v", err)
+			}
+
+			ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			const cnt = 1000
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for i := 0; i < cnt; i++ {
+					if err := rb.EnqueueCtx(ctx, i); err != nil {
+						t.Errorf("[PUT] failed: %v", err)
+						return
+					}
+				}
+			}()
+			for i := 0; i < cnt; i++ {
+				it, err := rb.DequeueCtx(ctx)
+				if err != nil || it != i {
+					t.Fatalf("expect %v but got %v, err: %v", i, it, err)
+				}
+			}
+			<-done
+		})
+	}
+}
+
+func TestBackoffWait(t *testing.T) {
+	s := BackoffWait(time.Microsecond, 4*time.Microsecond).(backoffWait)
+	for _, n := range []int{1, 2, 3, 64} {
+		s.Idle(n) // should never overflow or sleep longer than max
+	}
+	if s = BackoffWait(0, -1).(backoffWait); s.min != time.Microsecond || s.max != s.min {
+		t.Fatalf("unexpected normalized backoff: %+v", s)
+	}
+}
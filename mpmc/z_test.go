@@ -23,12 +23,7 @@ func newRingBuf[T any](capacity uint32, opts ...Opt[T]) (ringBuffer *ringBuf[T])
 		//	// rb.logger.Debug("[ringbuf][INI] ", zap.Uint32("cap", rb.cap), zap.Uint32("capModMask", rb.capModMask))
 		// }
 
-		for i := 0; i < int(size); i++ {
-			ringBuffer.data[i].readWrite &= 0 // bit 0: readable, bit 1: writable
-			if ringBuffer.initializer != nil {
-				ringBuffer.data[i].value = ringBuffer.initializer.PreAlloc(i)
-			}
-		}
+		ringBuffer.preAlloc()
 	}
 	return
 }
@@ -0,0 +1,104 @@
+package mpmc
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+	"weak"
+)
+
+type payload struct {
+	buf []byte
+}
+
+func TestZeroOnDequeue(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		create func(capacity uint32, opts ...Opt[*payload]) RingBuffer[*payload]
+	}{
+		{"MPMC", New[*payload]},
+		{"SPSC", NewSPSC[*payload]},
+		{"MPSC", NewMPSC[*payload]},
+		{"Sequenced", NewSequenced[*payload]},
+		{"Overlapped", func(capacity uint32, opts ...Opt[*payload]) RingBuffer[*payload] {
+			return NewOverlappedRingBuffer(capacity, opts...)
+		}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.create(4)
+			defer rb.Close()
+
+			p := &payload{buf: make([]byte, 1<<20)}
+			wp := weak.Make(p)
+			checkerr(t, rb.Enqueue(p))
+			checkerr(t, rb.Enqueue(&payload{}))
+			if _, err := rb.Dequeue(); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			ptr, ticket, err := rb.Acquire()
+			checkerr(t, err)
+			_ = *ptr
+			rb.Release(ticket)
+
+			p = nil //nolint:ineffassign,wastedassign
+			runtime.GC()
+			if wp.Value() != nil {
+				t.Fatal("expect the dequeued payload reclaimed")
+			}
+		})
+	}
+}
+
+func TestZeroOnDequeue_Reset(t *testing.T) {
+	rb := New[*payload](4, WithZeroOnDequeue[*payload](false))
+	defer rb.Close()
+
+	p := &payload{buf: make([]byte, 1<<20)}
+	wp := weak.Make(p)
+	checkerr(t, rb.Enqueue(p))
+	if _, err := rb.Dequeue(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	p = nil //nolint:ineffassign,wastedassign
+	runtime.GC()
+	if wp.Value() == nil {
+		t.Fatal("expect the payload retained without zeroing")
+	}
+
+	rb.Reset()
+	runtime.GC()
+	if wp.Value() != nil {
+		t.Fatal("expect the payload released by Reset")
+	}
+}
+
+func TestHasPointers(t *testing.T) {
+	type plain struct {
+		a int
+		b [4]float64
+	}
+	type mixed struct {
+		a int
+		s string
+	}
+	for typ, expect := range map[string]bool{
+		"int":     hasPointers(reflect.TypeFor[int]()),
+		"plain":   hasPointers(reflect.TypeFor[plain]()),
+		"[0]*int": hasPointers(reflect.TypeFor[[0]*int]()),
+	} {
+		if expect {
+			t.Fatalf("expect no pointers in %v", typ)
+		}
+	}
+	for typ, expect := range map[string]bool{
+		"string":  hasPointers(reflect.TypeFor[string]()),
+		"mixed":   hasPointers(reflect.TypeFor[mixed]()),
+		"[]byte":  hasPointers(reflect.TypeFor[[]byte]()),
+		"any":     hasPointers(reflect.TypeFor[any]()),
+		"[2]*int": hasPointers(reflect.TypeFor[[2]*int]()),
+	} {
+		if !expect {
+			t.Fatalf("expect pointers in %v", typ)
+		}
+	}
+}
@@ -0,0 +1,396 @@
+package mpmc
+
+import (
+	"sync/atomic"
+)
+
+// Ticket identifies a slot reserved by Reserve or acquired by
+// Acquire. It must be passed back to Commit or Release exactly once.
+//
+// The ring buffers on the 64-bit positions, i.e. [NewSequenced],
+// [NewLarge] and [NewGrowable], check the whole position and ignore
+// a ticket which has been passed back or never been handed out. The
+// others keep the slot index only, which can't tell the laps apart,
+// so passing such a ticket is undefined behavior: it might publish
+// or free a slot of another operation.
+type Ticket struct {
+	pos uint64
+}
+
+// Reserve claims a free slot for writing in place, and returns a
+// pointer to its value. The slot is invisible to the consumers till
+// Commit is called with the returned ticket.
+//
+// The pointer must not be used after Commit. [Initializeable.CloneIn]
+// is not applied to the value written in this way.
+func (rb *ringBuf[T]) Reserve() (ptr *T, ticket Ticket, err error) { //nolint:revive
+	if err = rb.enter(producing); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			rb.leave(producing) // keep registered till Commit
+		}
+	}()
+
+	var tail, head, nt uint32
+	if rb.IsClosed() {
+		err = ErrQueueClosed
+		return
+	}
+	for {
+		head = atomic.LoadUint32(&rb.head)
+		tail = atomic.LoadUint32(&rb.tail)
+		nt = (tail + 1) & rb.capModMask
+
+		isFull := nt == head
+		if isFull {
+			err = rb.full()
+			return
+		}
+		isEmpty := head == tail
+		if isEmpty && head == MaxUint32 {
+			err = rb.notReady()
+			return
+		}
+
+		if !atomic.CompareAndSwapUint32(&rb.tail, tail, nt) {
+			rb.retried()
+			continue // tail CAS failed, retry with fresh values
+		}
+		holder := rb.at(tail)
+		rb.claim(holder, 0, 2) //nolint:gomnd
+		ptr, ticket = &holder.value, Ticket{pos: uint64(tail)}
+		return
+	}
+}
+
+// Commit publishes the slot reserved by Reserve to the consumers.
+func (rb *ringBuf[T]) Commit(ticket Ticket) { //nolint:revive
+	holder := rb.at(uint32(ticket.pos) & rb.capModMask)
+	if atomic.LoadUint64(&holder.readWrite) != 2 { //nolint:gomnd
+		return // not reserved, see [Ticket] for a misused one
+	}
+	rb.stamp(holder)
+	if atomic.CompareAndSwapUint64(&holder.readWrite, 2, 1) { //nolint:gomnd
+		rb.produced(1, unseen)
+		rb.leave(producing)
+	}
+}
+
+// Acquire claims the head element for reading in place, and returns
+// a pointer to its value. The slot is not reused by the producers
+// till Release is called with the returned ticket.
+//
+// The pointer must not be used after Release. [Initializeable.CloneOut]
+// is not applied to the value read in this way.
+func (rb *ringBuf[T]) Acquire() (ptr *T, ticket Ticket, err error) { //nolint:revive
+	if err = rb.enter(consuming); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			rb.leave(consuming) // keep registered till Release
+		}
+	}()
+
+	var tail, head, nh uint32
+	for {
+		head = atomic.LoadUint32(&rb.head)
+		tail = atomic.LoadUint32(&rb.tail)
+
+		isEmpty := head == tail
+		if isEmpty {
+			if head == MaxUint32 {
+				err = rb.notReady()
+				return
+			}
+			if rb.IsClosed() {
+				err = ErrQueueClosed
+				return
+			}
+			err = rb.empty()
+			return
+		}
+
+		nh = (head + 1) & rb.capModMask
+		if !atomic.CompareAndSwapUint32(&rb.head, head, nh) {
+			rb.retried()
+			continue // head CAS failed, retry with fresh values
+		}
+		holder := rb.at(head)
+		rb.claim(holder, 1, 3) //nolint:gomnd
+		ptr, ticket = &holder.value, Ticket{pos: uint64(head)}
+		return
+	}
+}
+
+// Release gives the slot acquired by Acquire back to the producers.
+func (rb *ringBuf[T]) Release(ticket Ticket) { //nolint:revive
+	holder := rb.at(uint32(ticket.pos) & rb.capModMask)
+	if atomic.LoadUint64(&holder.readWrite) != 3 { //nolint:gomnd
+		return // not acquired, see [Ticket] for a misused one
+	}
+	rb.resided(holder)
+	rb.wipe(holder)
+	if atomic.CompareAndSwapUint64(&holder.readWrite, 3, 0) { //nolint:gomnd
+		rb.consumed(1)
+		rb.leave(consuming)
+	}
+}
+
+// Reserve claims a slot for writing in place, the oldest element
+// will be overwritten if the ring buffer is full.
+func (rb *orbuf[T]) Reserve() (ptr *T, ticket Ticket, err error) { //nolint:revive
+	if err = rb.enter(producing); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			rb.leave(producing) // keep registered till Commit
+		}
+	}()
+
+	var tail, head, nt uint32
+	if rb.IsClosed() {
+		err = ErrQueueClosed
+		return
+	}
+	for {
+		head = atomic.LoadUint32(&rb.head)
+		tail = atomic.LoadUint32(&rb.tail)
+		nt = (tail + 1) & rb.capModMask
+
+		isEmpty := head == tail
+		if isEmpty && head == MaxUint32 {
+			err = rb.notReady()
+			return
+		}
+
+		isFull := nt == head
+		if isFull && atomic.CompareAndSwapUint32(&rb.head, head, (head+1)&rb.capModMask) {
+			rb.overwritten(1)
+		}
+
+		if !atomic.CompareAndSwapUint32(&rb.tail, tail, nt) {
+			rb.retried()
+			continue // tail CAS failed, retry with fresh values
+		}
+		holder := rb.at(tail)
+		for spins := 0; !atomic.CompareAndSwapUint64(&holder.readWrite, 0, 2) && //nolint:gomnd
+			!atomic.CompareAndSwapUint64(&holder.readWrite, 1, 2); { //nolint:gomnd
+			spins++
+			rb.idle(spins)
+		}
+		ptr, ticket = &holder.value, Ticket{pos: uint64(tail)}
+		return
+	}
+}
+
+// Reserve claims a free slot for writing in place, see also
+// [ringBuf.Reserve].
+//
+// The single producer must Commit a reservation before reserving
+// the next one.
+func (rb *topoRingBuf[T]) Reserve() (ptr *T, ticket Ticket, err error) { //nolint:revive
+	if err = rb.enter(producing); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			rb.leave(producing) // keep registered till Commit
+		}
+	}()
+
+	var tail uint32
+	if tail, _, _, err = rb.reserve(1); err != nil {
+		return
+	}
+	holder := rb.writable(tail)
+	// the slot is marked even in SPSC mode, to catch a misused ticket
+	// in the common cases, see [Ticket].
+	atomic.StoreUint64(&holder.readWrite, 2) //nolint:gomnd
+	ptr, ticket = &holder.value, Ticket{pos: uint64(tail)}
+	return
+}
+
+// Commit publishes the slot reserved by Reserve to the consumers.
+func (rb *topoRingBuf[T]) Commit(ticket Ticket) { //nolint:revive
+	tail := uint32(ticket.pos)
+	holder := rb.at(tail & rb.capModMask)
+	if atomic.LoadUint64(&holder.readWrite) != 2 { //nolint:gomnd
+		return // not reserved, see [Ticket] for a misused one
+	}
+	rb.stamp(holder)
+	if !rb.multiProducers && !rb.multiConsumers {
+		atomic.StoreUint64(&holder.readWrite, 0) // no slot states in SPSC mode
+	}
+	rb.published(holder)
+	rb.publish(tail, 1, unseen)
+	rb.leave(producing)
+}
+
+// Acquire claims the head element for reading in place, see also
+// [ringBuf.Acquire].
+//
+// The single consumer must Release an acquired slot before
+// acquiring the next one.
+func (rb *topoRingBuf[T]) Acquire() (ptr *T, ticket Ticket, err error) { //nolint:revive
+	if err = rb.enter(consuming); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			rb.leave(consuming) // keep registered till Release
+		}
+	}()
+
+	var head uint32
+	if head, _, err = rb.acquire(1); err != nil {
+		return
+	}
+	holder := rb.readable(head)
+	if !rb.multiProducers && !rb.multiConsumers {
+		atomic.StoreUint64(&holder.readWrite, 3) //nolint:gomnd // see Reserve
+	}
+	ptr, ticket = &holder.value, Ticket{pos: uint64(head)}
+	return
+}
+
+// Release gives the slot acquired by Acquire back to the producers.
+func (rb *topoRingBuf[T]) Release(ticket Ticket) { //nolint:revive
+	head := uint32(ticket.pos)
+	holder := rb.at(head & rb.capModMask)
+	if atomic.LoadUint64(&holder.readWrite) != 3 { //nolint:gomnd
+		return // not acquired, see [Ticket] for a misused one
+	}
+	rb.resided(holder)
+	rb.wipe(holder)
+	atomic.StoreUint64(&holder.readWrite, 0)
+	rb.release(head, 1)
+	rb.leave(consuming)
+}
+
+// Reserve claims a free slot for writing in place, see also
+// [ringBuf.Reserve].
+func (rb *seqRingBuf[T]) Reserve() (ptr *T, ticket Ticket, err error) { //nolint:revive
+	if err = rb.enter(producing); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			rb.leave(producing) // keep registered till Commit
+		}
+	}()
+
+	if rb.IsClosed() {
+		err = ErrQueueClosed
+		return
+	}
+
+	var holder *rbItem[T]
+	pos := atomic.LoadUint64(&rb.enqPos)
+	for {
+		if pos&sealedPos != 0 {
+			err = rb.full() // sealed, the producers move on to the next segment
+			return
+		}
+		holder = rb.slot(pos)
+		seq := rb.loadSeq(holder)
+		if dif := int64(seq - pos); dif == 0 {
+			if atomic.CompareAndSwapUint64(&rb.enqPos, pos, pos+1) {
+				break
+			}
+			rb.retried()
+			pos = atomic.LoadUint64(&rb.enqPos)
+		} else if dif < 0 {
+			err = rb.full()
+			return
+		} else {
+			pos = atomic.LoadUint64(&rb.enqPos)
+		}
+	}
+
+	ptr, ticket = &holder.value, Ticket{pos: pos}
+	return
+}
+
+// Commit publishes the slot reserved by Reserve to the consumers.
+func (rb *seqRingBuf[T]) Commit(ticket Ticket) { //nolint:revive
+	rb.commit(ticket)
+}
+
+// commit publishes a reserved slot, and reports whether the ticket
+// is valid: the slot has been claimed, and not published yet.
+func (rb *seqRingBuf[T]) commit(ticket Ticket) bool {
+	pos, holder := ticket.pos, rb.slot(ticket.pos)
+	if pos >= atomic.LoadUint64(&rb.enqPos)&^sealedPos || rb.loadSeq(holder) != pos {
+		return false
+	}
+	rb.stamp(holder)
+	if !atomic.CompareAndSwapUint64(&holder.readWrite, pos, pos+1) {
+		return false
+	}
+	rb.produced(1, pos+1)
+	rb.leave(producing)
+	return true
+}
+
+// Acquire claims the head element for reading in place, see also
+// [ringBuf.Acquire].
+func (rb *seqRingBuf[T]) Acquire() (ptr *T, ticket Ticket, err error) { //nolint:revive
+	if err = rb.enter(consuming); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			rb.leave(consuming) // keep registered till Release
+		}
+	}()
+
+	var holder *rbItem[T]
+	pos := atomic.LoadUint64(&rb.deqPos)
+	for {
+		holder = rb.slot(pos)
+		seq := rb.loadSeq(holder)
+		if dif := int64(seq - (pos + 1)); dif == 0 {
+			if atomic.CompareAndSwapUint64(&rb.deqPos, pos, pos+1) {
+				break
+			}
+			rb.retried()
+			pos = atomic.LoadUint64(&rb.deqPos)
+		} else if dif < 0 {
+			err = rb.errEmpty()
+			return
+		} else {
+			pos = atomic.LoadUint64(&rb.deqPos)
+		}
+	}
+
+	rb.await(holder, pos+1)
+	ptr, ticket = &holder.value, Ticket{pos: pos}
+	return
+}
+
+// Release gives the slot acquired by Acquire back to the producers.
+func (rb *seqRingBuf[T]) Release(ticket Ticket) { //nolint:revive
+	rb.release(ticket)
+}
+
+// release frees an acquired slot, and reports whether the ticket is
+// valid: the slot has been claimed, and not freed yet.
+func (rb *seqRingBuf[T]) release(ticket Ticket) bool {
+	pos, holder := ticket.pos, rb.slot(ticket.pos)
+	if pos >= atomic.LoadUint64(&rb.deqPos) || rb.loadSeq(holder) != pos+1 {
+		return false
+	}
+	rb.resided(holder)
+	rb.wipe(holder)
+	// a peeker might hold the slot for a moment, see [peekedSeq].
+	for !atomic.CompareAndSwapUint64(&holder.readWrite, pos+1, pos+rb.size) {
+		rb.await(holder, pos+1)
+	}
+	rb.consumed(1)
+	rb.leave(consuming)
+	return true
+}
@@ -0,0 +1,210 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+type packet struct {
+	seq     int
+	payload [4096]byte
+}
+
+func TestRingBuf_ReserveCommit(t *testing.T) {
+	for _, c := range allCreators(1) {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.create(4)
+			defer rb.Close()
+
+			ptr, ticket, err := rb.Reserve()
+			checkerr(t, err)
+			*ptr = 1
+			rb.Commit(ticket)
+
+			ptr, ticket, err = rb.Acquire()
+			if err != nil || *ptr != 1 {
+				t.Fatalf("expect 1 but got %v, err: %v", *ptr, err)
+			}
+			rb.Release(ticket)
+			if _, _, err = rb.Acquire(); !errors.Is(err, ErrQueueEmpty) {
+				t.Fatalf("expect ErrQueueEmpty but got %v", err)
+			}
+
+			for i := uint32(0); i < rb.CapReal(); i++ {
+				checkerr(t, rb.Enqueue(int(i)))
+			}
+			ptr, ticket, err = rb.Reserve()
+			if c.name == "Overlapped" {
+				checkerr(t, err)
+				*ptr = -1
+				rb.Commit(ticket)
+				if it, err := rb.Dequeue(); err != nil || it != 1 {
+					t.Fatalf("expect the oldest overwritten, but got %v, err: %v", it, err)
+				}
+			} else if !errors.Is(err, ErrQueueFull) {
+				t.Fatalf("expect ErrQueueFull but got %v", err)
+			}
+			rb.Close()
+			if _, _, err = rb.Reserve(); !errors.Is(err, ErrQueueClosed) {
+				t.Fatalf("expect ErrQueueClosed but got %v", err)
+			}
+		})
+	}
+}
+
+func TestRingBuf_StaleTicket(t *testing.T) {
+	// only the ring buffers on the 64-bit positions tell a stale ticket.
+	creators := []struct {
+		name   string
+		create func(capacity uint32, opts ...Opt[int]) RingBuffer[int]
+	}{
+		{"Sequenced", NewSequenced[int]},
+		{"Large", func(capacity uint32, opts ...Opt[int]) RingBuffer[int] {
+			rb, _ := NewLarge(uint64(capacity), opts...)
+			return rb
+		}},
+		{"Growable", func(capacity uint32, opts ...Opt[int]) RingBuffer[int] {
+			return NewGrowable(capacity, capacity, opts...)
+		}},
+	}
+	for _, c := range creators {
+		t.Run(c.name, func(t *testing.T) {
+			rb := c.create(4)
+			defer rb.Close()
+
+			rb.Commit(Ticket{pos: 1})
+			rb.Release(Ticket{pos: 2})
+			ptr, ticket, err := rb.Reserve()
+			checkerr(t, err)
+			*ptr = 1
+			rb.Commit(ticket)
+			rb.Commit(ticket)
+			if rb.Size() != 1 {
+				t.Fatalf("expect an element committed once, but got %v", rb.Size())
+			}
+			_, ticket, err = rb.Acquire()
+			checkerr(t, err)
+			rb.Release(ticket)
+			rb.Release(ticket)
+			checkerr(t, rb.Enqueue(2))
+
+			done := make(chan []int)
+			go func() { done <- rb.Clear() }()
+			select {
+			case items := <-done:
+				if len(items) != 1 || items[0] != 2 {
+					t.Fatalf("unexpected elements cleared: %v", items)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Clear hangs after the stale tickets")
+			}
+		})
+	}
+}
+
+func TestOverlappedRingBuf_Reserve(t *testing.T) {
+	rb := NewOverlappedRingBuffer[int](4)
+	defer rb.Close()
+
+	for i := 0; i < 5; i++ {
+		ptr, ticket, err := rb.Reserve()
+		checkerr(t, err)
+		*ptr = i
+		rb.Commit(ticket)
+	}
+	for i := 2; i < 5; i++ {
+		if it, err := rb.Dequeue(); err != nil || it != i {
+			t.Fatalf("expect %v but got %v, err: %v", i, it, err)
+		}
+	}
+}
+
+func TestRingBuf_ZeroCopyConcurrent(t *testing.T) {
+	const producers, consumers, cnt = 2, 2, 2000
+
+	for _, rb := range []RingBuffer[packet]{New[packet](8), NewSequenced[packet](8)} {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		sum, got := 0, 0
+		for i := 0; i < producers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 1; j <= cnt && ctx.Err() == nil; {
+					ptr, ticket, err := rb.Reserve()
+					if err != nil {
+						runtime.Gosched()
+						continue
+					}
+					ptr.seq, ptr.payload[len(ptr.payload)-1] = j, byte(j)
+					rb.Commit(ticket)
+					j++
+				}
+			}()
+		}
+		for i := 0; i < consumers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for ctx.Err() == nil {
+					mu.Lock()
+					if got == producers*cnt {
+						mu.Unlock()
+						return
+					}
+					mu.Unlock()
+
+					ptr, ticket, err := rb.Acquire()
+					if err != nil {
+						runtime.Gosched()
+						continue
+					}
+					if ptr.payload[len(ptr.payload)-1] != byte(ptr.seq) {
+						t.Errorf("corrupted packet %v", ptr.seq)
+					}
+					mu.Lock()
+					sum += ptr.seq
+					got++
+					mu.Unlock()
+					rb.Release(ticket)
+				}
+			}()
+		}
+		wg.Wait()
+		cancel()
+		rb.Close()
+
+		if expect := producers * cnt * (cnt + 1) / 2; sum != expect {
+			t.Fatalf("expect sum %v but got %v", expect, sum)
+		}
+	}
+}
+
+// go test ./mpmc -bench 'BenchmarkRingBuf_(Copy|ZeroCopy)' -run=none
+func BenchmarkRingBuf_Copy(b *testing.B) {
+	rb := New[packet](1024)
+	var p packet
+	for i := 0; i < b.N; i++ {
+		p.seq = i
+		_ = rb.Enqueue(p)
+		p, _ = rb.Dequeue()
+	}
+}
+
+func BenchmarkRingBuf_ZeroCopy(b *testing.B) {
+	rb := New[packet](1024)
+	for i := 0; i < b.N; i++ {
+		ptr, ticket, _ := rb.Reserve()
+		ptr.seq = i
+		rb.Commit(ticket)
+		ptr, ticket, _ = rb.Acquire()
+		_ = ptr.seq
+		rb.Release(ticket)
+	}
+}